@@ -167,3 +167,31 @@ func BenchmarkBasicLogsParallel(b *testing.B) {
 		})
 	})
 }
+
+func BenchmarkDisabledLogs(b *testing.B) {
+	ionlog.SetAttributes(
+		ionlog.WithQueueSize(1000),
+		ionlog.WithMinLevel(ionlog.WarnLevel),
+	)
+
+	ionlog.Start()
+	defer ionlog.Stop()
+
+	b.Run("Debug", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ionlog.Debug(fakeMessage)
+		}
+	})
+
+	b.Run("Debugf", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ionlog.Debugf("log: %v", fakeMessage)
+		}
+	})
+
+	b.Run("Infow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ionlog.Infow(fakeMessage, "key", "value")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package ionlog
+
+import (
+	"context"
+	"slices"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/memory"
+)
+
+// fieldsContextKey is the key of the fields stored in a context.Context.
+type fieldsContextKey struct{}
+
+// logOnceScopeContextKey is the key of the log once memory stored in a context.Context.
+type logOnceScopeContextKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying the given fields,
+// added to the fields already stored in ctx.
+// The keys and values are handled in the same way of the Infow function.
+// The fields are added to the entries logged with the Ctx functions.
+func ContextWithFields(ctx context.Context, keysAndValues ...any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fields := slices.Concat(FieldsFromContext(ctx), logengine.ToFields(keysAndValues...))
+	return context.WithValue(ctx, fieldsContextKey{}, fields)
+}
+
+// FieldsFromContext returns the fields stored in ctx by ContextWithFields.
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	return fields
+}
+
+// ContextWithLogOnceScope returns a copy of ctx carrying a new log once scope,
+// the messages of LogOnceKeyCtx are logged once per scope, as once per request.
+// The records of the scope are dropped when ctx is done.
+func ContextWithLogOnceScope(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	scope := memory.NewRecordMemory()
+	context.AfterFunc(ctx, scope.Reset)
+
+	return context.WithValue(ctx, logOnceScopeContextKey{}, scope)
+}
+
+// logOnceScope returns the log once memory stored in ctx by ContextWithLogOnceScope.
+func logOnceScope(ctx context.Context) memory.IRecordMemory {
+	if ctx == nil {
+		return nil
+	}
+
+	scope, _ := ctx.Value(logOnceScopeContextKey{}).(memory.IRecordMemory)
+	return scope
+}
@@ -0,0 +1,125 @@
+package ionlog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContextWithFields(t *testing.T) {
+	t.Run("should store the fields on the context", func(t *testing.T) {
+		ctx := ContextWithFields(context.Background(), "request_id", "abc")
+		ctx = ContextWithFields(ctx, Int("attempt", 2))
+
+		expectedFields := []Field{{Key: "request_id", Value: "abc"}, {Key: "attempt", Value: 2}}
+		if fields := FieldsFromContext(ctx); !reflect.DeepEqual(fields, expectedFields) {
+			t.Errorf("expected fields to be %v, but got %v", expectedFields, fields)
+		}
+	})
+
+	t.Run("should not change the fields of the parent context", func(t *testing.T) {
+		parent := ContextWithFields(context.Background(), "a", 1)
+		_ = ContextWithFields(parent, "b", 2)
+
+		expectedFields := []Field{{Key: "a", Value: 1}}
+		if fields := FieldsFromContext(parent); !reflect.DeepEqual(fields, expectedFields) {
+			t.Errorf("expected fields to be %v, but got %v", expectedFields, fields)
+		}
+	})
+
+	t.Run("should return no fields when the context has none", func(t *testing.T) {
+		if fields := FieldsFromContext(context.Background()); fields != nil {
+			t.Errorf("expected no fields, but got %v", fields)
+		}
+	})
+}
+
+func TestInfoCtx(t *testing.T) {
+	t.Run("should add the context fields before the entry fields", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		ctx := ContextWithFields(context.Background(), "request_id", "abc")
+		l.With("service", "billing").InfoCtx(ctx, "charged", "amount", 10)
+		l.InfoCtx(context.Background(), "no context fields")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+
+		e := entries[0]
+		if e["service"] != "billing" || e["request_id"] != "abc" || e["amount"] != float64(10) {
+			t.Errorf("expected the bound, context and entry fields, but got %v", e)
+		}
+		if e["function"] != "TestInfoCtx.func1" {
+			t.Errorf("expected function to be %q, but got %q", "TestInfoCtx.func1", e["function"])
+		}
+
+		if _, ok := entries[1]["request_id"]; ok {
+			t.Errorf("expected no context field, but got %v", entries[1])
+		}
+	})
+}
+
+func TestLogOnceKeyCtx(t *testing.T) {
+	t.Run("should log the key once per scope", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		for _, requestID := range []string{"r1", "r2"} {
+			ctx := ContextWithLogOnceScope(ContextWithFields(context.Background(), "request_id", requestID))
+			for range 3 {
+				l.LogOnceKeyCtx(ctx, "deprecated-header", WarnLevel, "deprecated header")
+			}
+		}
+
+		l.Stop()
+
+		var requests []any
+		for _, e := range buf.entries(t) {
+			requests = append(requests, e["request_id"])
+		}
+		if expected := []any{"r1", "r2"}; !reflect.DeepEqual(requests, expected) {
+			t.Errorf("expected one entry per request %v, but got %v", expected, requests)
+		}
+	})
+
+	t.Run("should drop the records of the scope when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx = ContextWithLogOnceScope(ctx)
+
+		scope := logOnceScope(ctx)
+		scope.LoadOrAddRecord(1, "")
+
+		cancel()
+
+		deadline := time.Now().Add(time.Second)
+		for scope.Stats().Records != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if records := scope.Stats().Records; records != 0 {
+			t.Errorf("expected the records to be dropped, but got %d", records)
+		}
+	})
+
+	t.Run("should use the memory of the logger without a scope", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		l.LogOnceKeyCtx(context.Background(), "key", InfoLevel, "first")
+		l.LogOnceKey("key", InfoLevel, "second")
+
+		l.Stop()
+
+		if entries := buf.entries(t); len(entries) != 1 || entries[0]["msg"] != "first" {
+			t.Errorf("expected only the first entry, but got %v", entries)
+		}
+	})
+}
@@ -3,11 +3,69 @@ package ionlog
 import (
 	"os"
 
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
 	"github.com/IonicHealthUsa/ionlog/internal/core/rotationengine"
-	"github.com/IonicHealthUsa/ionlog/internal/service"
+	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/memory"
 	"github.com/IonicHealthUsa/ionlog/internal/styles"
 )
 
+// Level is the severity of a log entry.
+type Level = logengine.Level
+
+// Sampling limits the entries written by a call site: the first entries of every interval
+// are written, and after them only every Thereafter-th entry is written.
+// A Sampling without interval does not sample the entries.
+type Sampling = logengine.Sampling
+
+// RecordStats are the metrics of the records kept by the log once and periodic log functions.
+type RecordStats = memory.Stats
+
+// BackpressurePolicy is what is done with the entries when the reports queue is full.
+type BackpressurePolicy = logengine.BackpressurePolicy
+
+// RetryPolicy retries the writes failed by transient errors.
+type RetryPolicy = logengine.RetryPolicy
+
+// CircuitBreaker disables the writers which keep failing and probes them again after a cooldown.
+type CircuitBreaker = logengine.CircuitBreaker
+
+// Formatter formats the JSON entries for a writer, the writers with the same formatter share the formatted entry.
+type Formatter = logengine.Formatter
+
+// ReportQueueStats are the metrics of the reports queue: the queued entries, its capacity,
+// the entries dropped by level and the entries spilled to disk.
+type ReportQueueStats = logengine.QueueStats
+
+// TimeFormat is how the time of the entries is written: a layout of time.Format,
+// or one of the Unix layouts written as numbers, in the local time or in UTC.
+type TimeFormat = logengine.TimeFormat
+
+const (
+	TraceLevel = logengine.Trace
+	DebugLevel = logengine.Debug
+	InfoLevel  = logengine.Info
+	WarnLevel  = logengine.Warn
+	ErrorLevel = logengine.Error
+	PanicLevel = logengine.Panic
+	FatalLevel = logengine.Fatal
+)
+
+const (
+	BlockTimeout = logengine.BlockTimeout
+	Block        = logengine.Block
+	DropNewest   = logengine.DropNewest
+	DropOldest   = logengine.DropOldest
+	SpillToDisk  = logengine.SpillToDisk
+)
+
+// The layouts of TimeFormat which write the time as the number of seconds,
+// milliseconds or nanoseconds since the Unix epoch.
+const (
+	UnixSeconds = logengine.UnixSeconds
+	UnixMillis  = logengine.UnixMillis
+	UnixNanos   = logengine.UnixNanos
+)
+
 const (
 	Daily   = rotationengine.Daily
 	Weekly  = rotationengine.Weekly
@@ -23,8 +81,21 @@ const (
 
 const DefaultLogFolder = "logs"
 
-var logger = service.NewCoreService()
+// logger is the default Logger used by the package level functions.
+var logger = New()
 
 var DefaultOutput = os.Stdout
 
 var CustomOutput = styles.CustomOutput
+
+// PrettyFormat formats the entries as the colored lines of CustomOutput, for the WriterFormatter option.
+// It reads the time with the time format of the logger, set by WithTimeFormat.
+var PrettyFormat = styles.PrettyFormatter(TimeFormat{})
+
+// PrettyFormatter returns the formatter of the colored lines for the entries with the time format,
+// the time is shown with its precision and zone. A logger gives its writers the formatter
+// of its own time format, so PrettyFormat and PrettyFormatter format the same lines on a logger.
+// usage: WithWriter(os.Stdout, ionlog.WriterFormatter(ionlog.PrettyFormatter(format)))
+func PrettyFormatter(format TimeFormat) Formatter {
+	return styles.PrettyFormatter(format)
+}
@@ -0,0 +1,68 @@
+package ionlog
+
+import (
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+)
+
+// Field is a typed key/value pair added to a single log entry.
+// It can be passed to the log functions in place of a key and its value.
+type Field = logengine.Field
+
+// String creates a field with a string value.
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a field with an integer value, written as a JSON number.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 creates a field with an integer value, written as a JSON number.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a field with a boolean value, written as a JSON boolean.
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 creates a field with a floating point value, written as a JSON number.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a field with a duration value,
+// written as a JSON number of nanoseconds.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Time creates a field with a time value, written as a RFC3339Nano string.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Any creates a field with any value.
+// Maps and slices are written as JSON objects and arrays,
+// other values are encoded with the encoding/json package.
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates a field which records the error under the "error" key,
+// its concrete type under "error_type" and the errors it wraps,
+// following errors.Unwrap and errors.Join, under "error_chain".
+// A nil error is written as null.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr creates a field which records the error in the same way of Err,
+// using key in place of "error".
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, Value: logengine.ErrorValue{Err: err}}
+}
@@ -0,0 +1,556 @@
+package ionlog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/memory"
+	"github.com/IonicHealthUsa/ionlog/internal/service"
+	"github.com/IonicHealthUsa/ionlog/internal/usecases"
+)
+
+// sampledDroppedKey is the key of the number of entries of the call site
+// discarded by the sampling since the last written one.
+const sampledDroppedKey = "sampled_dropped"
+
+// Logger is an independent logger, with its own writers, reports queue,
+// static fields and rotation service.
+// The package level functions use a default Logger.
+type Logger struct {
+	core   service.ICoreService
+	fields []Field
+}
+
+// New creates a new Logger configured with the given attributes.
+// The Logger must be started with Start before logging.
+func New(fns ...customAttrs) *Logger {
+	l := &Logger{core: service.NewCoreService()}
+	// the reports queue of a new Logger is empty, so there is nothing to flush
+	l.apply(fns...)
+	return l
+}
+
+// Start begin the logger reports when it does not running
+func (l *Logger) Start() {
+	startSync := sync.WaitGroup{}
+	startSync.Add(1)
+	go l.core.Start(&startSync)
+	startSync.Wait()
+}
+
+// Stop stop the logger reports, flushing any pending logs.
+// A stopped Logger cannot be started again.
+func (l *Logger) Stop() {
+	l.core.Stop()
+}
+
+// Flush flushes the reports to the output writers.
+func (l *Logger) Flush() {
+	l.core.LogEngine().FlushReports()
+}
+
+// Enabled reports whether the logger writes entries of the given level.
+// It can guard the construction of expensive log arguments.
+func (l *Logger) Enabled(level Level) bool {
+	return l.enabled(level)
+}
+
+// ResetLogOnce forgets the messages of the log once and periodic log functions,
+// so they are logged again.
+func (l *Logger) ResetLogOnce() {
+	l.core.LogEngine().Memory().Reset()
+}
+
+// LogOnceStats returns the number of records held for the log once and periodic log functions
+// and the number of records evicted by the limits of WithLogOnceMaxRecords and WithLogOnceTTL.
+func (l *Logger) LogOnceStats() RecordStats {
+	return l.core.LogEngine().Memory().Stats()
+}
+
+// QueueStats returns the metrics of the reports queue,
+// the dropped entries are counted by level so the lost entries can be alerted on.
+func (l *Logger) QueueStats() ReportQueueStats {
+	return l.core.LogEngine().QueueStats()
+}
+
+// With returns a child logger whose entries always carry the given fields,
+// the keys and values are handled in the same way of the Infow function.
+// The child shares the writers, reports queue and attributes of its parent,
+// so it does not need to be started nor stopped.
+func (l *Logger) With(keysAndValues ...any) *Logger {
+	return &Logger{
+		core:   l.core,
+		fields: slices.Concat(l.fields, logengine.ToFields(keysAndValues...)),
+	}
+}
+
+// SetAttributes sets the logger attributes
+// fns is a variadic parameter that accepts customAttrs
+func (l *Logger) SetAttributes(fns ...customAttrs) {
+	l.Flush()
+	l.apply(fns...)
+}
+
+// apply applies the attributes to the core service of the logger.
+func (l *Logger) apply(fns ...customAttrs) {
+	for _, fn := range fns {
+		fn(l.core)
+	}
+}
+
+// Info logs a message with level info.
+func (l *Logger) Info(msg string) {
+	l.log(logengine.Info, msg, nil, nil, nil)
+}
+
+// Infof logs a message with level info.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Infof(msg string, args ...any) {
+	l.log(logengine.Info, msg, args, nil, nil)
+}
+
+// Infow logs a message with level info.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) Infow(msg string, keysAndValues ...any) {
+	l.log(logengine.Info, msg, nil, nil, keysAndValues)
+}
+
+// InfoCtx logs a message with level info.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) InfoCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	l.log(logengine.Info, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Error logs a message with level error.
+func (l *Logger) Error(msg string) {
+	l.log(logengine.Error, msg, nil, nil, nil)
+}
+
+// Errorf logs a message with level error.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Errorf(msg string, args ...any) {
+	l.log(logengine.Error, msg, args, nil, nil)
+}
+
+// Errorw logs a message with level error.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) Errorw(msg string, keysAndValues ...any) {
+	l.log(logengine.Error, msg, nil, nil, keysAndValues)
+}
+
+// ErrorE logs a message with level error, recording the error as the Err field.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) ErrorE(err error, msg string, keysAndValues ...any) {
+	l.log(logengine.Error, msg, nil, nil, withErr(err, keysAndValues))
+}
+
+// ErrorCtx logs a message with level error.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	l.log(logengine.Error, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Warn logs a message with level warn.
+func (l *Logger) Warn(msg string) {
+	l.log(logengine.Warn, msg, nil, nil, nil)
+}
+
+// Warnf logs a message with level warn.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Warnf(msg string, args ...any) {
+	l.log(logengine.Warn, msg, args, nil, nil)
+}
+
+// Warnw logs a message with level warn.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) Warnw(msg string, keysAndValues ...any) {
+	l.log(logengine.Warn, msg, nil, nil, keysAndValues)
+}
+
+// WarnCtx logs a message with level warn.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) WarnCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	l.log(logengine.Warn, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Debug logs a message with level debug.
+func (l *Logger) Debug(msg string) {
+	l.log(logengine.Debug, msg, nil, nil, nil)
+}
+
+// Debugf logs a message with level debug.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Debugf(msg string, args ...any) {
+	l.log(logengine.Debug, msg, args, nil, nil)
+}
+
+// Debugw logs a message with level debug.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) Debugw(msg string, keysAndValues ...any) {
+	l.log(logengine.Debug, msg, nil, nil, keysAndValues)
+}
+
+// DebugCtx logs a message with level debug.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) DebugCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	l.log(logengine.Debug, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Trace logs a message with level trace only when trace mode is enable.
+func (l *Logger) Trace(msg string) {
+	l.log(logengine.Trace, msg, nil, nil, nil)
+}
+
+// Tracef logs a message with level trace only when trace mode is enable.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Tracef(msg string, args ...any) {
+	l.log(logengine.Trace, msg, args, nil, nil)
+}
+
+// Tracew logs a message with level trace only when trace mode is enable.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) Tracew(msg string, keysAndValues ...any) {
+	l.log(logengine.Trace, msg, nil, nil, keysAndValues)
+}
+
+// TraceCtx logs a message with level trace only when trace mode is enable.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) TraceCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	l.log(logengine.Trace, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Panic logs a message with level panic and then panics with the message.
+// The entry is written synchronously and the writers are synced before the panic.
+func (l *Logger) Panic(msg string) {
+	l.log(logengine.Panic, msg, nil, nil, nil)
+	l.panic(msg)
+}
+
+// Panicf logs a message with level panic and then panics with the message.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Panicf(msg string, args ...any) {
+	msg = formatMsg(msg, args)
+	l.log(logengine.Panic, msg, nil, nil, nil)
+	l.panic(msg)
+}
+
+// Fatal logs a message with level fatal and then exits the program with status 1.
+// The entry is written synchronously and the writers are synced before the exit.
+func (l *Logger) Fatal(msg string) {
+	l.log(logengine.Fatal, msg, nil, nil, nil)
+	l.exit()
+}
+
+// Fatalf logs a message with level fatal and then exits the program with status 1.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Fatalf(msg string, args ...any) {
+	l.log(logengine.Fatal, msg, args, nil, nil)
+	l.exit()
+}
+
+// LogOnceInfo logs a message with level info only once time.
+func (l *Logger) LogOnceInfo(msg string) {
+	l.logRecord(logengine.Info, msg, nil, nil, nil, logOnceCheck)
+}
+
+// LogOnceInfof logs a message with level info only once time.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) LogOnceInfof(msg string, args ...any) {
+	l.logRecord(logengine.Info, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceInfow logs a message with level info only once time.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceInfow(msg string, keysAndValues ...any) {
+	l.logRecord(logengine.Info, msg, nil, nil, keysAndValues, logOnceCheck)
+}
+
+// LogOnceError logs a message with level error only once time.
+func (l *Logger) LogOnceError(msg string) {
+	l.logRecord(logengine.Error, msg, nil, nil, nil, logOnceCheck)
+}
+
+// LogOnceErrorf logs a message with level error only once time.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) LogOnceErrorf(msg string, args ...any) {
+	l.logRecord(logengine.Error, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceErrorw logs a message with level error only once time.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceErrorw(msg string, keysAndValues ...any) {
+	l.logRecord(logengine.Error, msg, nil, nil, keysAndValues, logOnceCheck)
+}
+
+// LogOnceWarn logs a message with level warn only once time.
+func (l *Logger) LogOnceWarn(msg string) {
+	l.logRecord(logengine.Warn, msg, nil, nil, nil, logOnceCheck)
+}
+
+// LogOnceWarnf logs a message with level warn only once time.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) LogOnceWarnf(msg string, args ...any) {
+	l.logRecord(logengine.Warn, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceWarnw logs a message with level warn only once time.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceWarnw(msg string, keysAndValues ...any) {
+	l.logRecord(logengine.Warn, msg, nil, nil, keysAndValues, logOnceCheck)
+}
+
+// LogOnceDebug logs a message with level debug only once time.
+func (l *Logger) LogOnceDebug(msg string) {
+	l.logRecord(logengine.Debug, msg, nil, nil, nil, logOnceCheck)
+}
+
+// LogOnceDebugf logs a message with level debug only once time.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) LogOnceDebugf(msg string, args ...any) {
+	l.logRecord(logengine.Debug, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceDebugw logs a message with level debug only once time.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceDebugw(msg string, keysAndValues ...any) {
+	l.logRecord(logengine.Debug, msg, nil, nil, keysAndValues, logOnceCheck)
+}
+
+// LogOnceKey logs a message with the level only on the first call with the key,
+// in place of the call site, it suits the messages logged once per device or user.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceKey(key string, level Level, msg string, keysAndValues ...any) {
+	l.logRecord(level, msg, nil, nil, keysAndValues, onceKeyCheck(key))
+}
+
+// LogOnceKeyCtx logs a message with the level only on the first call with the key
+// in the log once scope of ctx, created by ContextWithLogOnceScope.
+// Without a scope, it is the same as LogOnceKey.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func (l *Logger) LogOnceKeyCtx(ctx context.Context, key string, level Level, msg string, keysAndValues ...any) {
+	l.logRecord(level, msg, nil, FieldsFromContext(ctx), keysAndValues, scopedOnceKeyCheck(ctx, key))
+}
+
+// LogEveryN logs a message with the level on the first call of the call site
+// and then on every n-th call, it suits the status messages of polling loops.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogEveryN(level Level, n int, msg string, keysAndValues ...any) {
+	l.logRecord(level, msg, nil, nil, keysAndValues, everyNCheck(n))
+}
+
+// LogFirstN logs a message with the level only on the first n calls of the call site.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogFirstN(level Level, n int, msg string, keysAndValues ...any) {
+	l.logRecord(level, msg, nil, nil, keysAndValues, firstNCheck(n))
+}
+
+// LogEvery logs a message with the level at most once in every period d for the call site.
+// The keys and values are added as fields of this entry only.
+func (l *Logger) LogEvery(level Level, d time.Duration, msg string, keysAndValues ...any) {
+	l.logRecord(level, msg, nil, nil, keysAndValues, everyCheck(d))
+}
+
+// formatMsg formats the message in the manner of fmt.Sprintf
+// only when there are arguments.
+func formatMsg(msg string, args []any) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// withErr returns the keys and values preceded by the Err field of the error.
+func withErr(err error, keysAndValues []any) []any {
+	return append([]any{Err(err)}, keysAndValues...)
+}
+
+// enabled reports whether the entries of the level are written for the caller of the Enabled function,
+// it must be called directly by the Enabled functions to keep the caller stack depth.
+func (l *Logger) enabled(level Level) bool {
+	engine := l.core.LogEngine()
+
+	if !engine.Enabled(level) {
+		return false
+	}
+	if !engine.HasLevelRules() {
+		return true
+	}
+
+	return engine.EnabledFor(level, runtimeinfo.GetCallerInfo(engine.GetCallerStackDepth()+1))
+}
+
+// entryFields returns the bound fields of the logger,
+// followed by the context fields and the entry fields.
+func (l *Logger) entryFields(ctxFields []Field, fields []Field) []Field {
+	if len(l.fields) == 0 && len(ctxFields) == 0 {
+		return fields
+	}
+	return slices.Concat(l.fields, ctxFields, fields)
+}
+
+// log sends the report of the function which called the log level,
+// it must be called directly by the log functions to keep the caller stack depth.
+// Nothing is done when the level is not enabled.
+func (l *Logger) log(level logengine.Level, msg string, args []any, ctxFields []Field, keysAndValues []any) {
+	engine := l.core.LogEngine()
+
+	if !engine.Enabled(level) {
+		return
+	}
+
+	depth := engine.GetCallerStackDepth() + 1
+
+	callerInfo := runtimeinfo.GetCallerInfo(depth)
+	if !engine.EnabledFor(level, callerInfo) {
+		return
+	}
+
+	written, sampled, dropped := engine.Sample(level, callerInfo)
+	if !written {
+		return
+	}
+
+	fields := l.entryFields(ctxFields, logengine.ToFields(keysAndValues...))
+	if sampled {
+		fields = append(fields, Field{Key: sampledDroppedKey, Value: dropped})
+	}
+
+	r := logengine.ReportType{
+		Time:       time.Now(),
+		Level:      level,
+		Msg:        formatMsg(msg, args),
+		CallerInfo: callerInfo,
+		Fields:     fields,
+	}
+	if engine.StackTraceEnabled(level) {
+		r.Stack = runtimeinfo.GetStack(depth)
+	}
+
+	l.report(r)
+}
+
+// report sends the report to the log engine,
+// trace, panic and fatal reports are sent synchronously, the others asynchronously.
+// The queued reports are written before the panic and fatal reports, so they keep their order.
+func (l *Logger) report(r logengine.ReportType) {
+	if r.Level >= logengine.Panic {
+		l.core.LogEngine().FlushReports()
+	}
+
+	if r.Level == logengine.Trace || r.Level >= logengine.Panic {
+		l.core.LogEngine().Report(r)
+		return
+	}
+
+	l.core.LogEngine().AsyncReport(r)
+}
+
+// sync writes the queued reports and syncs the writers, as the log files.
+func (l *Logger) sync() {
+	engine := l.core.LogEngine()
+	engine.FlushReports()
+	engine.Writer().Sync()
+}
+
+// panic syncs the logger and panics with the message.
+func (l *Logger) panic(msg string) {
+	l.sync()
+	panic(msg)
+}
+
+// exit syncs the logger and exits the program with status 1.
+func (l *Logger) exit() {
+	l.sync()
+	l.core.Exit(1)
+}
+
+// recordCheck reports whether the log of the call site is sent,
+// using the records of the call sites kept in the memory.
+type recordCheck func(logsMemory memory.IRecordMemory, msg string, ci runtimeinfo.CallerInfo) bool
+
+// logOnceCheck sends the log when the message of the call site changes.
+func logOnceCheck(logsMemory memory.IRecordMemory, msg string, ci runtimeinfo.CallerInfo) bool {
+	return usecases.LogOnce(logsMemory, msg, ci.File, ci.Package, ci.Function)
+}
+
+// onceKeyCheck sends only the first log with the key.
+func onceKeyCheck(key string) recordCheck {
+	return func(logsMemory memory.IRecordMemory, _ string, _ runtimeinfo.CallerInfo) bool {
+		return usecases.LogOnceKey(logsMemory, key)
+	}
+}
+
+// scopedOnceKeyCheck sends only the first log with the key in the log once scope of ctx,
+// it uses the memory of the logger when ctx has no scope.
+func scopedOnceKeyCheck(ctx context.Context, key string) recordCheck {
+	return func(logsMemory memory.IRecordMemory, _ string, _ runtimeinfo.CallerInfo) bool {
+		if scope := logOnceScope(ctx); scope != nil {
+			logsMemory = scope
+		}
+		return usecases.LogOnceKey(logsMemory, key)
+	}
+}
+
+// everyNCheck sends the first log of the call site and then every n-th log.
+func everyNCheck(n int) recordCheck {
+	return func(logsMemory memory.IRecordMemory, _ string, ci runtimeinfo.CallerInfo) bool {
+		return usecases.LogEveryN(logsMemory, n, ci.File, ci.Package, ci.Function, strconv.Itoa(ci.Line))
+	}
+}
+
+// firstNCheck sends only the first n logs of the call site.
+func firstNCheck(n int) recordCheck {
+	return func(logsMemory memory.IRecordMemory, _ string, ci runtimeinfo.CallerInfo) bool {
+		return usecases.LogFirstN(logsMemory, n, ci.File, ci.Package, ci.Function, strconv.Itoa(ci.Line))
+	}
+}
+
+// everyCheck sends at most one log of the call site in every period d.
+func everyCheck(d time.Duration) recordCheck {
+	return func(logsMemory memory.IRecordMemory, _ string, ci runtimeinfo.CallerInfo) bool {
+		return usecases.LogEvery(logsMemory, d, time.Now(), ci.File, ci.Package, ci.Function, strconv.Itoa(ci.Line))
+	}
+}
+
+// logRecord sends the report of the function which called the log level
+// when the check of its record allows it,
+// it must be called directly by the log functions to keep the caller stack depth.
+func (l *Logger) logRecord(level logengine.Level, msg string, args []any, ctxFields []Field, keysAndValues []any, check recordCheck) {
+	engine := l.core.LogEngine()
+
+	if !engine.Enabled(level) {
+		return
+	}
+
+	depth := engine.GetCallerStackDepth() + 1
+
+	callerInfo := runtimeinfo.GetCallerInfo(depth)
+	if !engine.EnabledFor(level, callerInfo) {
+		return
+	}
+
+	recordMsg := formatMsg(msg, args)
+
+	if !check(engine.Memory(), recordMsg, callerInfo) {
+		return
+	}
+
+	r := logengine.ReportType{
+		Time:       time.Now(),
+		Level:      level,
+		Msg:        recordMsg,
+		CallerInfo: callerInfo,
+		Fields:     l.entryFields(ctxFields, logengine.ToFields(keysAndValues...)),
+	}
+	if engine.StackTraceEnabled(level) {
+		r.Stack = runtimeinfo.GetStack(depth)
+	}
+
+	l.report(r)
+}
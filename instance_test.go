@@ -0,0 +1,848 @@
+package ionlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockBufferWriter struct {
+	lock  sync.Mutex
+	buf   bytes.Buffer
+	syncs int
+}
+
+func (m *mockBufferWriter) Write(p []byte) (n int, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.buf.Write(p)
+}
+
+func (m *mockBufferWriter) Sync() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.syncs++
+	return nil
+}
+
+// entries decodes every log line written on the buffer.
+func (m *mockBufferWriter) entries(t *testing.T) []map[string]any {
+	t.Helper()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(m.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected a valid JSON line, but got %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should log to its own writers", func(t *testing.T) {
+		buf1 := &mockBufferWriter{}
+		buf2 := &mockBufferWriter{}
+
+		l1 := New(WithWriters(buf1))
+		l2 := New(WithWriters(buf2), WithStaticFields(map[string]string{"subsystem": "billing"}))
+		l1.Start()
+		l2.Start()
+
+		l1.Info("from l1")
+		l2.Infow("from l2", "attempt", 1)
+
+		l1.Stop()
+		l2.Stop()
+
+		e1 := buf1.entries(t)
+		if len(e1) != 1 || e1[0]["msg"] != "from l1" {
+			t.Fatalf("expected only the l1 entry, but got %v", e1)
+		}
+		if _, ok := e1[0]["subsystem"]; ok {
+			t.Errorf("expected no static field on l1, but got %v", e1[0])
+		}
+
+		e2 := buf2.entries(t)
+		if len(e2) != 1 || e2[0]["msg"] != "from l2" {
+			t.Fatalf("expected only the l2 entry, but got %v", e2)
+		}
+		if e2[0]["subsystem"] != "billing" || e2[0]["attempt"] != float64(1) {
+			t.Errorf("expected the static field and the entry field on l2, but got %v", e2[0])
+		}
+	})
+
+	t.Run("should report the caller of the log function", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithTraceMode(true))
+		l.Start()
+
+		l.Infof("hello %s", "world")
+		l.Trace("trace")
+		l.LogOnceWarn("once")
+		l.LogOnceWarn("once")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 3 {
+			t.Fatalf("expected 3 entries, but got %v", entries)
+		}
+
+		for _, e := range entries {
+			if e["file"] != "instance_test.go" {
+				t.Errorf("expected file to be %q, but got %q", "instance_test.go", e["file"])
+			}
+			if e["function"] != "TestNew.func2" {
+				t.Errorf("expected function to be %q, but got %q", "TestNew.func2", e["function"])
+			}
+		}
+
+		// trace is synchronous, so it is written before the queued entries
+		msgs := []any{entries[0]["msg"], entries[1]["msg"], entries[2]["msg"]}
+		expectedMsgs := []any{"trace", "hello world", "once"}
+		if !reflect.DeepEqual(msgs, expectedMsgs) {
+			t.Errorf("expected messages to be %v, but got %v", expectedMsgs, msgs)
+		}
+	})
+
+	t.Run("should not wait for the flush of the empty reports queue", func(t *testing.T) {
+		// every flush waits 1ms for the idle queue, so 100 flushes take at least 100ms
+		start := time.Now()
+		for range 100 {
+			New(WithWriters(&mockBufferWriter{}))
+		}
+
+		if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+			t.Errorf("expected New to return without the flush, but 100 calls took %v", elapsed)
+		}
+	})
+}
+
+func TestWith(t *testing.T) {
+	t.Run("should add the bound fields on every entry of the child", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		parent := New(WithWriters(buf))
+		parent.Start()
+
+		child := parent.With("request_id", "abc")
+		grandchild := child.With(Int("attempt", 2))
+
+		child.Info("child")
+		grandchild.Infow("grandchild", "step", "upload")
+		parent.Info("parent")
+
+		parent.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 3 {
+			t.Fatalf("expected 3 entries, but got %v", entries)
+		}
+
+		if entries[0]["request_id"] != "abc" {
+			t.Errorf("expected the child entry to have the bound field, but got %v", entries[0])
+		}
+
+		if entries[1]["request_id"] != "abc" || entries[1]["attempt"] != float64(2) || entries[1]["step"] != "upload" {
+			t.Errorf("expected the grandchild entry to have all fields, but got %v", entries[1])
+		}
+
+		if _, ok := entries[2]["request_id"]; ok {
+			t.Errorf("expected the parent entry to have no bound field, but got %v", entries[2])
+		}
+	})
+
+	t.Run("should not share the fields between siblings", func(t *testing.T) {
+		parent := New().With("a", 1)
+
+		s1 := parent.With("b", 2)
+		s2 := parent.With("c", 3)
+
+		if len(s1.fields) != 2 || s1.fields[1].Key != "b" {
+			t.Errorf("expected the fields of s1 to be [a b], but got %v", s1.fields)
+		}
+		if len(s2.fields) != 2 || s2.fields[1].Key != "c" {
+			t.Errorf("expected the fields of s2 to be [a c], but got %v", s2.fields)
+		}
+	})
+}
+
+func TestWithMinLevel(t *testing.T) {
+	t.Run("should discard the entries below the min level", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithMinLevel(WarnLevel))
+		l.Start()
+
+		l.Debugf("debug %d", 1)
+		l.Infow("info", "key", "value")
+		l.LogOnceInfo("once")
+		l.Warn("warn")
+		l.Error("error")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 || entries[0]["msg"] != "warn" || entries[1]["msg"] != "error" {
+			t.Errorf("expected only the warn and error entries, but got %v", entries)
+		}
+	})
+
+	t.Run("should report the enabled levels", func(t *testing.T) {
+		l := New(WithMinLevel(InfoLevel))
+
+		if l.Enabled(DebugLevel) {
+			t.Error("expected the debug level to be disabled")
+		}
+		if !l.Enabled(InfoLevel) {
+			t.Error("expected the info level to be enabled")
+		}
+	})
+}
+
+func TestWithLevelRules(t *testing.T) {
+	t.Run("should use the level of the rule matching the caller", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(
+			WithWriters(buf),
+			WithMinLevel(WarnLevel),
+			WithLevelRules(map[string]Level{"ionlog.TestWithLevelRules*": DebugLevel}),
+		)
+		l.Start()
+
+		l.Debug("debug")
+		if !l.Enabled(DebugLevel) {
+			t.Error("expected the debug level to be enabled for the caller")
+		}
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 || entries[0]["msg"] != "debug" {
+			t.Errorf("expected only the debug entry, but got %v", entries)
+		}
+	})
+
+	t.Run("should use the min level for the callers without rules", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(
+			WithWriters(buf),
+			WithMinLevel(WarnLevel),
+			WithLevelRules(map[string]Level{"otherpkg": DebugLevel}),
+		)
+		l.Start()
+
+		l.Debug("debug")
+		l.Warn("warn")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 || entries[0]["msg"] != "warn" {
+			t.Errorf("expected only the warn entry, but got %v", entries)
+		}
+	})
+
+	t.Run("should remove the rules", func(t *testing.T) {
+		l := New(
+			WithMinLevel(WarnLevel),
+			WithLevelRules(map[string]Level{"ionlog": DebugLevel}),
+		)
+		l.SetAttributes(WithoutLevelRules("ionlog"))
+
+		if l.Enabled(DebugLevel) {
+			t.Error("expected the debug level to be disabled")
+		}
+	})
+}
+
+func TestPanic(t *testing.T) {
+	t.Run("should write the entries and sync the writers before the panic", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+		defer l.Stop()
+
+		l.Info("before")
+
+		defer func() {
+			r := recover()
+			if r != "failed 42" {
+				t.Errorf("expected to panic with %q, but got %v", "failed 42", r)
+			}
+
+			var levels []any
+			for _, e := range buf.entries(t) {
+				levels = append(levels, e["msg"], e["level"])
+			}
+			expected := []any{"before", "INFO", "failed 42", "PANIC"}
+			if !reflect.DeepEqual(levels, expected) {
+				t.Errorf("expected the queued entry before the panic, but got %v", levels)
+			}
+			if buf.syncs != 1 {
+				t.Errorf("expected the writer to be synced once, but got %d", buf.syncs)
+			}
+		}()
+
+		l.Panicf("failed %d", 42)
+	})
+}
+
+func TestFatal(t *testing.T) {
+	t.Run("should write the entry, sync the writers and exit with status 1", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		code := -1
+		l := New(WithWriters(buf), WithExitFunc(func(c int) { code = c }))
+		l.Start()
+		defer l.Stop()
+
+		l.Info("before")
+		l.Fatal("fatal")
+
+		if code != 1 {
+			t.Errorf("expected the exit code to be 1, but got %d", code)
+		}
+
+		entries := buf.entries(t)
+		if len(entries) != 2 || entries[0]["msg"] != "before" || entries[1]["msg"] != "fatal" || entries[1]["level"] != "FATAL" {
+			t.Errorf("expected the queued entry before the fatal entry, but got %v", entries)
+		}
+		if buf.syncs != 1 {
+			t.Errorf("expected the writer to be synced once, but got %d", buf.syncs)
+		}
+	})
+}
+
+func TestErrorE(t *testing.T) {
+	t.Run("should record the error, its type and its chain", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		err := fmt.Errorf("charge: %w", &os.PathError{Op: "open", Path: "inv-1.pdf", Err: os.ErrNotExist})
+		l.ErrorE(err, "charge failed", "invoice", "inv-1")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected one entry, but got %v", entries)
+		}
+
+		e := entries[0]
+		if e["error"] != "charge: open inv-1.pdf: file does not exist" || e["error_type"] != "*fmt.wrapError" || e["invoice"] != "inv-1" {
+			t.Errorf("expected the error fields, but got %v", e)
+		}
+
+		chain, ok := e["error_chain"].([]any)
+		if !ok || len(chain) != 3 {
+			t.Fatalf("expected a chain of three errors, but got %v", e["error_chain"])
+		}
+		if cause, _ := chain[1].(map[string]any); cause["type"] != "*fs.PathError" {
+			t.Errorf("expected the type of the wrapped error, but got %v", chain[1])
+		}
+	})
+}
+
+// stackWrapper wraps the log function, as the wrappers which use WithCallerInfoDepth
+func stackWrapper(l *Logger, msg string) {
+	l.Error(msg)
+}
+
+// firstStackFunction returns the function of the first frame of the stack of the entry.
+func firstStackFunction(t *testing.T, entry map[string]any) string {
+	t.Helper()
+
+	stack, ok := entry["stack"].([]any)
+	if !ok || len(stack) == 0 {
+		t.Fatalf("expected the entry to have a stack, but got %v", entry)
+	}
+	frame, _ := stack[0].(map[string]any)
+	function, _ := frame["function"].(string)
+	return function
+}
+
+func TestWithStackTraceLevel(t *testing.T) {
+	t.Run("should add the stack to the entries of the level and above", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithStackTraceLevel(ErrorLevel))
+		l.Start()
+
+		l.Warn("warn")
+		l.Error("error")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+		if _, ok := entries[0]["stack"]; ok {
+			t.Errorf("expected no stack on the warn entry, but got %v", entries[0])
+		}
+		if f := firstStackFunction(t, entries[1]); !strings.HasSuffix(f, ".TestWithStackTraceLevel.func1") {
+			t.Errorf("expected the stack to start at the caller, but got %q", f)
+		}
+	})
+
+	t.Run("should skip the frames of the caller stack depth", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithStackTraceLevel(ErrorLevel), WithCallerInfoDepth(3))
+		l.Start()
+
+		stackWrapper(l, "error")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		if f := firstStackFunction(t, entries[0]); !strings.HasSuffix(f, ".TestWithStackTraceLevel.func2") {
+			t.Errorf("expected the stack to skip the wrapper, but got %q", f)
+		}
+	})
+
+	t.Run("should not add the stack when it is disabled", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithStackTraceLevel(ErrorLevel), WithoutStackTrace())
+		l.Start()
+
+		l.Error("error")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		if _, ok := entries[0]["stack"]; ok {
+			t.Errorf("expected no stack, but got %v", entries[0])
+		}
+	})
+}
+
+func TestWithSampling(t *testing.T) {
+	t.Run("should sample the entries of the call site", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(
+			WithWriters(buf),
+			WithSampling(Sampling{First: 2, Thereafter: 5, Interval: time.Hour}),
+			WithLevelSampling(ErrorLevel, Sampling{}),
+		)
+		l.Start()
+
+		for i := range 10 {
+			l.Warnf("warn %d", i)
+		}
+		l.Error("error")
+
+		l.Stop()
+
+		var msgs []any
+		var dropped []any
+		for _, e := range buf.entries(t) {
+			msgs = append(msgs, e["msg"])
+			dropped = append(dropped, e["sampled_dropped"])
+		}
+
+		expectedMsgs := []any{"warn 0", "warn 1", "warn 6", "error"}
+		if !reflect.DeepEqual(msgs, expectedMsgs) {
+			t.Errorf("expected the entries %v, but got %v", expectedMsgs, msgs)
+		}
+
+		expectedDropped := []any{float64(0), float64(0), float64(4), nil}
+		if !reflect.DeepEqual(dropped, expectedDropped) {
+			t.Errorf("expected the dropped counts %v, but got %v", expectedDropped, dropped)
+		}
+	})
+}
+
+func TestLogEvery(t *testing.T) {
+	t.Run("should log the first call and then every n-th call", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		for i := range 5 {
+			l.LogEveryN(InfoLevel, 2, "polling", "attempt", i)
+		}
+
+		l.Stop()
+
+		var attempts []any
+		for _, e := range buf.entries(t) {
+			attempts = append(attempts, e["attempt"])
+		}
+		expected := []any{float64(0), float64(2), float64(4)}
+		if !reflect.DeepEqual(attempts, expected) {
+			t.Errorf("expected the attempts %v, but got %v", expected, attempts)
+		}
+	})
+
+	t.Run("should log only the first n calls", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		for i := range 5 {
+			l.LogFirstN(WarnLevel, 2, "starting", "attempt", i)
+		}
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 || entries[0]["level"] != "WARN" || entries[1]["attempt"] != float64(1) {
+			t.Errorf("expected the first two entries, but got %v", entries)
+		}
+	})
+
+	t.Run("should log once in the period", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		for range 5 {
+			l.LogEvery(InfoLevel, time.Hour, "waiting")
+		}
+
+		l.Stop()
+
+		if entries := buf.entries(t); len(entries) != 1 {
+			t.Errorf("expected one entry, but got %v", entries)
+		}
+	})
+}
+
+func TestResetLogOnce(t *testing.T) {
+	t.Run("should log the message again after the reset", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		for range 2 {
+			l.LogOnceInfo("once")
+			l.ResetLogOnce()
+		}
+
+		l.Stop()
+
+		if entries := buf.entries(t); len(entries) != 2 {
+			t.Errorf("expected two entries, but got %v", entries)
+		}
+	})
+}
+
+func TestWithLogOnceMaxRecords(t *testing.T) {
+	t.Run("should forget the least recently used records", func(t *testing.T) {
+		l := New(WithLogOnceMaxRecords(1))
+
+		l.LogOnceInfo("first")
+		l.LogFirstN(InfoLevel, 1, "second")
+
+		if stats := l.LogOnceStats(); stats != (RecordStats{Records: 1, Evictions: 1}) {
+			t.Errorf("expected the stats to be %+v, but got %+v", RecordStats{Records: 1, Evictions: 1}, stats)
+		}
+	})
+}
+
+func TestWithDuplicateSuppression(t *testing.T) {
+	t.Run("should collapse the repeated entries", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithDuplicateSuppression(time.Hour))
+		l.Start()
+
+		for range 5 {
+			l.Warn("disk full")
+		}
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 || entries[1]["repeat_count"] != float64(4) {
+			t.Errorf("expected the first entry and the summary of the repeats, but got %v", entries)
+		}
+	})
+}
+
+func TestWithBackpressurePolicy(t *testing.T) {
+	t.Run("should count the dropped entries by level", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(
+			WithWriters(buf),
+			WithQueueSize(1),
+			WithBackpressurePolicy(DropNewest),
+			WithLevelBackpressurePolicy(ErrorLevel, SpillToDisk),
+		)
+
+		// the reports are not handled before Start, so the queue is full after the first entry
+		l.Info("first")
+		l.Info("second")
+		l.Error("third")
+
+		stats := l.QueueStats()
+		if stats.Dropped[InfoLevel] != 1 || stats.Dropped[ErrorLevel] != 0 {
+			t.Errorf("expected one dropped info entry, but got %v", stats.Dropped)
+		}
+		if stats.Spilled != 1 {
+			t.Errorf("expected one spilled entry, but got %v", stats.Spilled)
+		}
+
+		l.Start()
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 || entries[0]["msg"] != "first" || entries[1]["msg"] != "third" {
+			t.Errorf("expected the first and the error entries, but got %v", entries)
+		}
+	})
+}
+
+func TestWithWriter(t *testing.T) {
+	t.Run("should write the levels of every writer in its format", func(t *testing.T) {
+		json := &mockBufferWriter{}
+		pretty := &mockBufferWriter{}
+		alerts := &mockBufferWriter{}
+
+		l := New(
+			WithWriter(json, WriterMinLevel(DebugLevel)),
+			WithWriter(pretty, WriterMinLevel(InfoLevel), WriterFormatter(PrettyFormat)),
+			WithWriter(alerts, WriterLevels(ErrorLevel)),
+		)
+		l.SetAttributes(WithMinLevel(DebugLevel))
+		l.Start()
+
+		l.Debug("debug")
+		l.Info("info")
+		l.Error("error")
+
+		l.Stop()
+
+		if entries := json.entries(t); len(entries) != 3 {
+			t.Errorf("expected 3 JSON entries, but got %v", entries)
+		}
+
+		lines := strings.Split(strings.TrimSpace(pretty.buf.String()), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], "info") || strings.HasPrefix(lines[0], "{") {
+			t.Errorf("expected 2 pretty lines, but got %q", lines)
+		}
+
+		if entries := alerts.entries(t); len(entries) != 1 || entries[0]["msg"] != "error" {
+			t.Errorf("expected only the error entry, but got %v", entries)
+		}
+	})
+}
+
+// panicWriter panics on its first write.
+type panicWriter struct {
+	mockBufferWriter
+	panicked bool
+}
+
+func (p *panicWriter) Write(b []byte) (int, error) {
+	if !p.panicked {
+		p.panicked = true
+		panic("write failed")
+	}
+	return p.mockBufferWriter.Write(b)
+}
+
+func TestWriterPanic(t *testing.T) {
+	t.Run("should release the writer after it panics", func(t *testing.T) {
+		w := &panicWriter{}
+		l := New(WithWriters(w))
+		l.Start()
+
+		l.Info("first")
+		l.Info("second")
+
+		stopped := make(chan struct{})
+		go func() {
+			l.Stop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected Stop to return after the writer panicked")
+		}
+
+		if entries := w.entries(t); len(entries) != 1 || entries[0]["msg"] != "second" {
+			t.Errorf("expected the second entry written, but got %v", entries)
+		}
+	})
+}
+
+// slowWriter delays every write.
+type slowWriter struct {
+	mockBufferWriter
+	delay time.Duration
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.mockBufferWriter.Write(p)
+}
+
+func TestWriterBuffer(t *testing.T) {
+	t.Run("should deliver the buffered entries on stop", func(t *testing.T) {
+		slow := &slowWriter{delay: 5 * time.Millisecond}
+		fast := &mockBufferWriter{}
+
+		l := New(
+			WithWriter(slow, WriterBuffer(100, Block)),
+			WithWriters(fast),
+		)
+		l.Start()
+
+		for i := range 10 {
+			l.Infow("entry", "n", i)
+		}
+
+		l.Stop()
+
+		if entries := fast.entries(t); len(entries) != 10 {
+			t.Errorf("expected 10 entries on the fast writer, but got %v", len(entries))
+		}
+		if entries := slow.entries(t); len(entries) != 10 {
+			t.Errorf("expected 10 entries on the slow writer, but got %v", len(entries))
+		}
+	})
+}
+
+func TestInfoAllocs(t *testing.T) {
+	t.Run("should not allocate on a plain Info call", func(t *testing.T) {
+		l := New(WithWriters(io.Discard))
+		l.Start()
+		defer l.Stop()
+
+		// resolves the call site before the allocations are counted
+		info := func() { l.Info("request received") }
+		info()
+		l.Flush()
+
+		if allocs := testing.AllocsPerRun(1000, info); allocs != 0 {
+			t.Errorf("expected no allocation, but got %v allocs per call", allocs)
+		}
+	})
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	t.Run("should write the time with the format", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithTimeFormat(TimeFormat{Layout: time.RFC3339Nano, UTC: true}))
+		l.Start()
+
+		l.Info("info")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		timeStr, _ := entries[0]["time"].(string)
+		ts, err := time.Parse(time.RFC3339Nano, timeStr)
+		if err != nil || ts.Location() != time.UTC {
+			t.Errorf("expected a RFC3339Nano time in UTC, but got %q", timeStr)
+		}
+	})
+
+	t.Run("should show the time of every logger with its own format", func(t *testing.T) {
+		unixFormat := TimeFormat{Layout: UnixSeconds, UTC: true}
+		nanoFormat := TimeFormat{Layout: time.RFC3339Nano, UTC: true}
+
+		unixBuf := &mockBufferWriter{}
+		unix := New(WithTimeFormat(unixFormat), WithWriter(unixBuf, WriterFormatter(PrettyFormatter(unixFormat))))
+		nanoBuf := &mockBufferWriter{}
+		nano := New(WithTimeFormat(nanoFormat), WithWriter(nanoBuf, WriterFormatter(PrettyFormatter(nanoFormat))))
+		unix.Start()
+		nano.Start()
+
+		unix.Info("unix")
+		nano.Info("nano")
+
+		unix.Stop()
+		nano.Stop()
+
+		rfc3339 := regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z`)
+		if line := unixBuf.buf.String(); !rfc3339.MatchString(line) {
+			t.Errorf("expected the Unix time shown as RFC3339, but got %q", line)
+		}
+		rfc3339Nano := regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d+Z`)
+		if line := nanoBuf.buf.String(); !rfc3339Nano.MatchString(line) {
+			t.Errorf("expected the time shown as RFC3339Nano, but got %q", line)
+		}
+	})
+
+	t.Run("should show the time of CustomOutput and PrettyFormat with the format of the logger", func(t *testing.T) {
+		customBuf := &mockBufferWriter{}
+		millisBuf := &mockBufferWriter{}
+		nanoBuf := &mockBufferWriter{}
+
+		millis := New(
+			WithTimeFormat(TimeFormat{Layout: UnixMillis, UTC: true}),
+			WithWriters(CustomOutput(customBuf)),
+			WithWriter(millisBuf, WriterFormatter(PrettyFormat)),
+		)
+		// the time format is set after the writer
+		nano := New(
+			WithWriter(nanoBuf, WriterFormatter(PrettyFormat)),
+			WithTimeFormat(TimeFormat{Layout: time.RFC3339Nano, UTC: true}),
+		)
+		millis.Start()
+		nano.Start()
+
+		millis.Info("millis")
+		nano.Info("nano")
+
+		millis.Stop()
+		nano.Stop()
+
+		rfc3339Millis := regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z`)
+		if line := customBuf.buf.String(); !rfc3339Millis.MatchString(line) {
+			t.Errorf("expected CustomOutput to show the Unix milliseconds with their precision, but got %q", line)
+		}
+		if line := millisBuf.buf.String(); !rfc3339Millis.MatchString(line) {
+			t.Errorf("expected PrettyFormat to show the Unix milliseconds with their precision, but got %q", line)
+		}
+
+		rfc3339Nano := regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d+Z`)
+		if line := nanoBuf.buf.String(); !rfc3339Nano.MatchString(line) {
+			t.Errorf("expected PrettyFormat to show the time as RFC3339Nano, but got %q", line)
+		}
+	})
+
+	t.Run("should write the Unix time as a number", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithTimeFormat(TimeFormat{Layout: UnixMillis}))
+		l.Start()
+
+		before := time.Now().UnixMilli()
+		l.Info("info")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		if ms, ok := entries[0]["time"].(float64); !ok || int64(ms) < before {
+			t.Errorf("expected the time as Unix milliseconds, but got %v", entries[0]["time"])
+		}
+	})
+}
@@ -1,21 +1,52 @@
 package logbuilder
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
 )
 
+const hex = "0123456789abcdef"
+
+// controlEscapes are the escape sequences of the control characters.
+var controlEscapes = func() (escapes [0x20]string) {
+	for b := range escapes {
+		escapes[b] = `\u00` + string(hex[b>>4]) + string(hex[b&0xF])
+	}
+	escapes['\n'], escapes['\r'], escapes['\t'], escapes['\b'], escapes['\f'] = `\n`, `\r`, `\t`, `\b`, `\f`
+	return escapes
+}()
+
 const bufsize = 1024
 const maxBufsize = bufsize * 512 // 1/2 MB
 
+// reserved is the room kept at the end of the buffer to close a truncated string and the entry.
+const reserved = len(`"}` + "\n")
+
 type logBuilder struct {
 	buf []byte
 	p   uint
+
+	// full is set when the entry reached the max size, the next fields are discarded,
+	// and broken is set when a write of the current field was discarded, so it is removed
+	full   bool
+	broken bool
 }
 
 type ILogBuilder interface {
 	AddFields(args ...string)
+	AddField(key string, value any)
+	AddString(key string, value string)
+	AddInt(key string, value int64)
+	AddTime(key string, t time.Time, layout string)
 	Compile() []byte
+	Reset()
 }
 
 // NewLogBuilder creates a new logBody with initialized fields map
@@ -26,28 +57,179 @@ func NewLogBuilder() ILogBuilder {
 	return lb
 }
 
-func (l *logBuilder) writeByte(b byte) {
-	if len(l.buf) >= maxBufsize {
-		fmt.Fprintf(os.Stderr, "logBuilder buffer is full, cannot handle more strings for this log entry.\n")
-		return
+// room reports whether n bytes fit the entry up to the limit, the buffer is grown when needed.
+func (l *logBuilder) room(n int, limit int) bool {
+	end := int(l.p) + n
+	if end > limit {
+		return false
 	}
-	if l.p == uint(len(l.buf)) {
-		newBuf := make([]byte, len(l.buf)+bufsize)
-		copy(newBuf, l.buf)
+
+	if end > len(l.buf) {
+		newBuf := make([]byte, min(max(len(l.buf)*2, end), maxBufsize))
+		copy(newBuf, l.buf[:l.p])
 		l.buf = newBuf
 	}
+	return true
+}
+
+// truncate marks the entry as full, the warning is written once per entry.
+func (l *logBuilder) truncate() {
+	if !l.full {
+		fmt.Fprintf(os.Stderr, "logBuilder buffer is full, the log entry is truncated.\n")
+	}
+	l.full = true
+}
+
+// discard marks the current field as broken, it is removed at the end of the field.
+func (l *logBuilder) discard() {
+	l.truncate()
+	l.broken = true
+}
+
+func (l *logBuilder) writeByte(b byte) {
+	if l.full || !l.room(1, maxBufsize-reserved) {
+		l.discard()
+		return
+	}
 	l.buf[l.p] = b
 	l.p++
 }
 
+// writeString copies the whole string, or nothing when it does not fit the entry.
 func (l *logBuilder) writeString(str string) {
-	for _, s := range []byte(str) {
-		l.writeByte(s)
+	if l.full || !l.room(len(str), maxBufsize-reserved) {
+		l.discard()
+		return
+	}
+	l.p += uint(copy(l.buf[l.p:], str))
+}
+
+func (l *logBuilder) writeBytes(b []byte) {
+	if l.full || !l.room(len(b), maxBufsize-reserved) {
+		l.discard()
+		return
+	}
+	l.p += uint(copy(l.buf[l.p:], b))
+}
+
+// writeClosing writes the byte which closes the entry or a truncated string,
+// in the room reserved for it.
+func (l *logBuilder) writeClosing(b byte) {
+	if !l.room(1, maxBufsize) {
+		l.discard()
+		return
+	}
+	l.buf[l.p] = b
+	l.p++
+}
+
+// writeContent writes the part of a string value, the string is truncated
+// when the part does not fit the entry. It returns false when the string is truncated.
+// A part which is not a run of plain characters is written whole or not at all,
+// so an escape sequence or a rune is never cut.
+func (l *logBuilder) writeContent(part string, plain bool) bool {
+	if l.full {
+		return false
+	}
+
+	if !l.room(len(part), maxBufsize-reserved) {
+		if plain {
+			n := max(maxBufsize-reserved-int(l.p), 0)
+			l.room(n, maxBufsize-reserved)
+			l.p += uint(copy(l.buf[l.p:], part[:n]))
+		}
+		l.truncate()
+		return false
+	}
+
+	l.p += uint(copy(l.buf[l.p:], part))
+	return true
+}
+
+// beginField writes the separator of a new field and returns its start,
+// the fields which do not fit the entry are removed by endField.
+func (l *logBuilder) beginField() uint {
+	start := l.p
+	if l.p > 1 {
+		l.writeByte(',')
+	}
+	return start
+}
+
+func (l *logBuilder) endField(start uint) {
+	if l.broken {
+		l.p = start
+		l.broken = false
 	}
 }
 
+// writeEscapedString writes the string escaped as described in RFC 8259,
+// invalid UTF-8 sequences are replaced by the Unicode replacement character.
+// The string is truncated when it does not fit the entry.
+func (l *logBuilder) writeEscapedString(str string) {
+	for i := 0; i < len(str); {
+		// the run of bytes which need no escape is written at once
+		start := i
+		for i < len(str) && str[i] >= 0x20 && str[i] < utf8.RuneSelf && str[i] != '"' && str[i] != '\\' {
+			i++
+		}
+		if start < i {
+			if !l.writeContent(str[start:i], true) {
+				return
+			}
+			continue
+		}
+
+		var part string
+		b := str[i]
+		if b < utf8.RuneSelf {
+			switch b {
+			case '"':
+				part = `\"`
+			case '\\':
+				part = `\\`
+			default:
+				part = controlEscapes[b]
+			}
+			i++
+		} else {
+			r, size := utf8.DecodeRuneInString(str[i:])
+			switch {
+			case r == utf8.RuneError && size == 1:
+				part = `\ufffd`
+			case r == '\u2028':
+				// valid JSON, but escaped to keep the line safe for JavaScript consumers
+				part = `\u2028`
+			case r == '\u2029':
+				part = `\u2029`
+			default:
+				part = str[i : i+size]
+			}
+			i += size
+		}
+
+		if !l.writeContent(part, false) {
+			return
+		}
+	}
+}
+
+// writeQuoted writes the string between quotes, a truncated string is closed in the reserved room.
+func (l *logBuilder) writeQuoted(str string) {
+	if l.full {
+		l.discard()
+		return
+	}
+
+	l.writeByte('"')
+	l.writeEscapedString(str)
+	l.writeClosing('"')
+}
+
 func (l *logBuilder) resetBuff() {
 	l.p = 0
+	l.full = false
+	l.broken = false
 	l.writeByte('{')
 }
 
@@ -57,23 +239,202 @@ func (l *logBuilder) AddFields(args ...string) {
 		return
 	}
 	for i := 0; i < len(args); i += 2 {
-		if l.p > 1 {
-			l.writeByte(',')
-		}
-		l.writeByte('"')
-		l.writeString(args[i])
-		l.writeByte('"')
+		start := l.beginField()
+		l.writeQuoted(args[i])
 		l.writeByte(':')
-
-		l.writeByte('"')
-		l.writeString(args[i+1])
-		l.writeByte('"')
+		l.writeQuoted(args[i+1])
+		l.endField(start)
 	}
 }
 
+// AddString adds a string field, without the allocation of the variadic AddFields.
+func (l *logBuilder) AddString(key string, value string) {
+	start := l.beginField()
+	l.writeQuoted(key)
+	l.writeByte(':')
+	l.writeQuoted(value)
+	l.endField(start)
+}
+
+// AddInt adds an integer field, without the allocation of the value boxed by AddField.
+func (l *logBuilder) AddInt(key string, value int64) {
+	start := l.beginField()
+	l.writeQuoted(key)
+	l.writeByte(':')
+
+	var scratch [20]byte
+	l.writeBytes(strconv.AppendInt(scratch[:0], value, 10))
+	l.endField(start)
+}
+
+// AddTime adds the time formatted by the layout straight into the buffer.
+func (l *logBuilder) AddTime(key string, t time.Time, layout string) {
+	start := l.beginField()
+	l.writeQuoted(key)
+	l.writeByte(':')
+
+	var scratch [64]byte
+	l.writeByte('"')
+	l.writeBytes(t.AppendFormat(scratch[:0], layout))
+	l.writeByte('"')
+	l.endField(start)
+}
+
+// Reset discards the fields added since the last Compile.
+func (l *logBuilder) Reset() {
+	l.resetBuff()
+}
+
+// Compile closes the entry and returns it, the entry is always a valid JSON line:
+// the string which reaches the max size is truncated and the next fields are discarded.
 func (l *logBuilder) Compile() []byte {
 	defer l.resetBuff()
-	l.writeString("}\n")
+	l.writeClosing('}')
+	l.writeClosing('\n')
 
 	return l.buf[:l.p]
 }
+
+// AddField adds a single field keeping the JSON type of the value.
+// Numbers, booleans and nil are written as JSON numbers, booleans and null,
+// maps and slices are written as JSON objects and arrays.
+// A time.Duration is written as an integer number of nanoseconds
+// and a time.Time as a RFC3339Nano string. The errors and the fmt.Stringer values
+// are written as their string, a nil pointer is written as "<nil>".
+func (l *logBuilder) AddField(key string, value any) {
+	start := l.beginField()
+	l.writeQuoted(key)
+	l.writeByte(':')
+	l.writeValue(value)
+	l.endField(start)
+}
+
+func (l *logBuilder) writeValue(value any) {
+	var scratch [64]byte
+
+	switch v := value.(type) {
+	case nil:
+		l.writeString("null")
+	case string:
+		l.writeQuoted(v)
+	case bool:
+		l.writeBytes(strconv.AppendBool(scratch[:0], v))
+	case int:
+		l.writeBytes(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int8:
+		l.writeBytes(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int16:
+		l.writeBytes(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int32:
+		l.writeBytes(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		l.writeBytes(strconv.AppendInt(scratch[:0], v, 10))
+	case uint:
+		l.writeBytes(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint8:
+		l.writeBytes(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint16:
+		l.writeBytes(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint32:
+		l.writeBytes(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		l.writeBytes(strconv.AppendUint(scratch[:0], v, 10))
+	case float32:
+		l.writeFloat(float64(v), 32)
+	case float64:
+		l.writeFloat(v, 64)
+	case time.Duration:
+		l.writeBytes(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case time.Time:
+		l.writeByte('"')
+		l.writeBytes(v.AppendFormat(scratch[:0], time.RFC3339Nano))
+		l.writeByte('"')
+	case map[string]any:
+		l.writeObject(v)
+	case []any:
+		l.writeArray(v)
+	case json.Marshaler:
+		l.writeJSON(v)
+	case error, fmt.Stringer:
+		l.writeQuoted(methodString(v))
+	default:
+		l.writeJSON(v)
+	}
+}
+
+// methodString returns the result of the Error or String method of the value,
+// a nil pointer is written as "<nil>" and a panic of the method as "PANIC=<value>",
+// so a value which cannot be printed does not stop the logging.
+func methodString(v any) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return "<nil>"
+	}
+
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeFloat writes the float as a JSON number,
+// NaN and infinities have no JSON representation and are written as strings.
+func (l *logBuilder) writeFloat(f float64, bitSize int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		l.writeQuoted(strconv.FormatFloat(f, 'g', -1, bitSize))
+		return
+	}
+
+	var scratch [64]byte
+	l.writeBytes(strconv.AppendFloat(scratch[:0], f, 'g', -1, bitSize))
+}
+
+func (l *logBuilder) writeObject(m map[string]any) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	l.writeByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			l.writeByte(',')
+		}
+		l.writeQuoted(k)
+		l.writeByte(':')
+		l.writeValue(m[k])
+	}
+	l.writeByte('}')
+}
+
+func (l *logBuilder) writeArray(a []any) {
+	l.writeByte('[')
+	for i, v := range a {
+		if i > 0 {
+			l.writeByte(',')
+		}
+		l.writeValue(v)
+	}
+	l.writeByte(']')
+}
+
+// writeJSON writes any other value using the encoding/json package,
+// when the value cannot be encoded its fmt representation is written as string.
+func (l *logBuilder) writeJSON(v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		l.writeQuoted(fmt.Sprint(v))
+		return
+	}
+	l.writeBytes(b)
+}
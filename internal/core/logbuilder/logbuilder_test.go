@@ -1,10 +1,19 @@
 package logbuilder
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/quick"
 	"time"
+	"unicode/utf8"
 )
 
 var fakeMessage = "We shall not cease from exploration and the end of all our exploring will be to arrive where we started and know the place for the first time."
@@ -298,3 +307,322 @@ func TestWriteByte(t *testing.T) {
 		}
 	})
 }
+
+type stringerValue struct{}
+
+func (stringerValue) String() string { return "stringer" }
+
+// pointerStringer dereferences the pointer in its String method.
+type pointerStringer struct{ name string }
+
+func (p *pointerStringer) String() string { return p.name }
+
+// pointerError dereferences the pointer in its Error method.
+type pointerError struct{ msg string }
+
+func (p *pointerError) Error() string { return p.msg }
+
+// panicStringer panics in its String method.
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("no string") }
+
+func TestAddField(t *testing.T) {
+	testCase := [...]struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{name: "nil", value: nil, expected: `null`},
+		{name: "string", value: "value", expected: `"value"`},
+		{name: "bool", value: true, expected: `true`},
+		{name: "int", value: 42, expected: `42`},
+		{name: "negative int64", value: int64(-42), expected: `-42`},
+		{name: "uint8", value: uint8(255), expected: `255`},
+		{name: "uint64", value: uint64(18446744073709551615), expected: `18446744073709551615`},
+		{name: "float64", value: 1.5, expected: `1.5`},
+		{name: "float32", value: float32(0.25), expected: `0.25`},
+		{name: "NaN", value: math.NaN(), expected: `"NaN"`},
+		{name: "infinity", value: math.Inf(1), expected: `"+Inf"`},
+		{name: "duration", value: 1500 * time.Millisecond, expected: `1500000000`},
+		{name: "time", value: time.Date(2025, 6, 17, 10, 30, 0, 500, time.UTC), expected: `"2025-06-17T10:30:00.0000005Z"`},
+		{name: "error", value: errors.New("failure"), expected: `"failure"`},
+		{name: "stringer", value: stringerValue{}, expected: `"stringer"`},
+		{name: "nil pointer stringer", value: (*pointerStringer)(nil), expected: `"<nil>"`},
+		{name: "nil pointer error", value: (*pointerError)(nil), expected: `"<nil>"`},
+		{name: "panicking stringer", value: panicStringer{}, expected: `"PANIC=no string"`},
+		{name: "object", value: map[string]any{"b": 1, "a": "x"}, expected: `{"a":"x","b":1}`},
+		{name: "array", value: []any{1, "two", false, nil}, expected: `[1,"two",false,null]`},
+		{name: "nested", value: map[string]any{"list": []any{map[string]any{"id": 1}}}, expected: `{"list":[{"id":1}]}`},
+		{name: "struct", value: struct {
+			ID int `json:"id"`
+		}{ID: 7}, expected: `{"id":7}`},
+		{name: "slice of strings", value: []string{"a", "b"}, expected: `["a","b"]`},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := NewLogBuilder()
+			lb.AddField("key", tt.value)
+
+			result := lb.Compile()
+			expected := []byte(`{"key":` + tt.expected + "}\n")
+
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("AddField incorrect, got: %s, want: %s", result, expected)
+			}
+		})
+	}
+
+	t.Run("Mixes AddFields and AddField", func(t *testing.T) {
+		lb := NewLogBuilder()
+		lb.AddFields("msg", "hello")
+		lb.AddField("line", 42)
+
+		result := lb.Compile()
+		expected := []byte("{\"msg\":\"hello\",\"line\":42}\n")
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("AddField after AddFields incorrect, got: %s, want: %s", result, expected)
+		}
+	})
+}
+
+func TestEscapedString(t *testing.T) {
+	testCase := [...]struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "quote", value: `say "hi"`, expected: `"say \"hi\""`},
+		{name: "backslash", value: `C:\logs`, expected: `"C:\\logs"`},
+		{name: "newline", value: "line1\nline2", expected: `"line1\nline2"`},
+		{name: "carriage return and tab", value: "a\r\tb", expected: `"a\r\tb"`},
+		{name: "backspace and form feed", value: "a\b\fb", expected: `"a\b\fb"`},
+		{name: "control character", value: "a\x00b\x1f", expected: `"a\u0000b\u001f"`},
+		{name: "invalid utf-8", value: "a\xffb", expected: `"a\ufffdb"`},
+		{name: "truncated utf-8", value: "a\xe2\x82", expected: `"a\ufffd\ufffd"`},
+		{name: "line separators", value: "a\u2028b\u2029", expected: `"a\u2028b\u2029"`},
+		{name: "multi byte characters", value: "ação 😀", expected: `"ação 😀"`},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := NewLogBuilder()
+			lb.AddFields(tt.value, tt.value)
+
+			result := lb.Compile()
+			expected := []byte("{" + tt.expected + ":" + tt.expected + "}\n")
+
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("escaping incorrect, got: %s, want: %s", result, expected)
+			}
+
+			if !json.Valid(result) {
+				t.Errorf("expected a valid JSON, but got %s", result)
+			}
+		})
+	}
+}
+
+// checkCompile asserts that the compiled line is a valid JSON object
+// which decodes back to the given key and value.
+func checkCompile(key, value string) error {
+	lb := NewLogBuilder()
+	lb.AddFields(key, value)
+	lb.AddField("nested", map[string]any{key: []any{value}})
+	line := lb.Compile()
+
+	if line[len(line)-1] != '\n' {
+		return fmt.Errorf("expected the line to end with a new line, but got %q", line)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return fmt.Errorf("expected a valid JSON for %q:%q, but got %q: %w", key, value, line, err)
+	}
+
+	if !utf8.ValidString(key) || !utf8.ValidString(value) {
+		return nil
+	}
+
+	if entry[key] != value {
+		return fmt.Errorf("expected the value of %q to be %q, but got %q", key, value, entry[key])
+	}
+
+	return nil
+}
+
+func TestCompileAlwaysValidJSON(t *testing.T) {
+	t.Run("should produce valid JSON for any key and value", func(t *testing.T) {
+		f := func(key, value string) bool {
+			if key == "nested" {
+				return true
+			}
+			if err := checkCompile(key, value); err != nil {
+				t.Log(err)
+				return false
+			}
+			return true
+		}
+
+		if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should produce valid JSON for every byte", func(t *testing.T) {
+		for b := 0; b < 256; b++ {
+			if err := checkCompile("key", string([]byte{byte(b)})); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	oversized := strings.Repeat("a", 600*1024)
+
+	testCase := [...]struct {
+		name string
+		add  func(lb ILogBuilder)
+	}{
+		{name: "string value", add: func(lb ILogBuilder) { lb.AddString("msg", oversized) }},
+		{name: "escaped string value", add: func(lb ILogBuilder) { lb.AddString("msg", strings.Repeat("\"\x01😀", 200*1024)) }},
+		{name: "key", add: func(lb ILogBuilder) { lb.AddFields(oversized, "value") }},
+		{name: "nested value", add: func(lb ILogBuilder) { lb.AddField("nested", map[string]any{"msg": oversized, "z": 1}) }},
+		{name: "array value", add: func(lb ILogBuilder) { lb.AddField("array", []any{oversized}) }},
+		{name: "many fields", add: func(lb ILogBuilder) {
+			for range 100000 {
+				lb.AddString("key", "value")
+			}
+		}},
+	}
+
+	for _, tt := range testCase {
+		t.Run("should produce valid JSON for an oversized "+tt.name, func(t *testing.T) {
+			lb := NewLogBuilder()
+			lb.AddString("first", "kept")
+			tt.add(lb)
+			lb.AddInt("line", 42)
+			line := lb.Compile()
+
+			if len(line) > maxBufsize || line[len(line)-1] != '\n' {
+				t.Fatalf("expected a line of at most %v bytes ending with a new line, but got %v bytes", maxBufsize, len(line))
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatalf("expected a valid JSON, but got %v", err)
+			}
+			if entry["first"] != "kept" {
+				t.Errorf("expected the fields before the oversized one to be kept, but got %v", entry["first"])
+			}
+			if _, ok := entry["line"]; ok {
+				t.Errorf("expected the fields after the oversized one to be discarded")
+			}
+
+			// the builder is usable after the oversized entry
+			lb.AddString("msg", "next")
+			if got := string(lb.Compile()); got != `{"msg":"next"}`+"\n" {
+				t.Errorf("expected the next entry to be written, but got %q", got)
+			}
+		})
+	}
+
+	t.Run("should truncate the string value inside its quotes", func(t *testing.T) {
+		oldStderr := os.Stderr
+		defer func() { os.Stderr = oldStderr }()
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+
+		lb := NewLogBuilder()
+		lb.AddString("msg", oversized)
+		lb.AddString("next", oversized)
+
+		w.Close()
+		warnings, _ := io.ReadAll(r)
+		if n := strings.Count(string(warnings), "\n"); n != 1 {
+			t.Errorf("expected one warning for the entry, but got %v", n)
+		}
+
+		var entry map[string]string
+		if err := json.Unmarshal(lb.Compile(), &entry); err != nil {
+			t.Fatalf("expected a valid JSON, but got %v", err)
+		}
+		if msg := entry["msg"]; len(msg) < maxBufsize-32 || !strings.HasPrefix(oversized, msg) {
+			t.Errorf("expected the message to be truncated near the max size, but got %v bytes", len(msg))
+		}
+	})
+}
+
+func FuzzCompile(f *testing.F) {
+	f.Add("msg", "hello world")
+	f.Add("quote\"", "back\\slash")
+	f.Add("ctrl", "\x00\x01\n\r\t\b\f")
+	f.Add("utf8", "😀\xff\xe2\x82\u2028")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		if key == "nested" {
+			return
+		}
+		if err := checkCompile(key, value); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestTypedFields(t *testing.T) {
+	t.Run("should write the string, int and time fields", func(t *testing.T) {
+		lb := NewLogBuilder()
+		ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		lb.AddString("msg", "say \"hi\"")
+		lb.AddInt("line", -42)
+		lb.AddTime("time", ts, time.RFC3339)
+
+		expected := `{"msg":"say \"hi\"","line":-42,"time":"2025-01-02T03:04:05Z"}` + "\n"
+		if got := string(lb.Compile()); got != expected {
+			t.Errorf("expected %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("should not allocate", func(t *testing.T) {
+		lb := NewLogBuilder()
+		ts := time.Now()
+
+		allocs := testing.AllocsPerRun(100, func() {
+			lb.AddString("msg", fakeMessage)
+			lb.AddInt("line", 42)
+			lb.AddTime("time", ts, time.RFC3339)
+			_ = lb.Compile()
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocation, but got %v", allocs)
+		}
+	})
+}
+
+func TestPool(t *testing.T) {
+	t.Run("should return a builder without fields", func(t *testing.T) {
+		p := NewPool()
+
+		lb := p.Get()
+		lb.AddString("key", "value")
+		p.Put(lb)
+
+		if got := string(p.Get().Compile()); got != "{}\n" {
+			t.Errorf("expected an empty entry, but got %q", got)
+		}
+	})
+
+	t.Run("should not keep the large builders", func(t *testing.T) {
+		p := NewPool()
+
+		lb := p.Get()
+		lb.AddString("key", strings.Repeat("a", maxPooledBufsize+1))
+		p.Put(lb)
+
+		if _lb, ok := p.Get().(*logBuilder); ok && len(_lb.buf) > maxPooledBufsize {
+			t.Errorf("expected the large builder to be dropped, but got a buffer of %v bytes", len(_lb.buf))
+		}
+	})
+}
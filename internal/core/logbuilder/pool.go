@@ -0,0 +1,34 @@
+package logbuilder
+
+import "sync"
+
+// maxPooledBufsize is the largest buffer kept by the pool,
+// the builders of the larger entries are left to the garbage collector.
+const maxPooledBufsize = bufsize * 64
+
+// Pool keeps the builders between the entries, so the entries are encoded
+// by many goroutines without allocating a builder for every entry.
+type Pool struct {
+	pool sync.Pool
+}
+
+func NewPool() *Pool {
+	p := &Pool{}
+	p.pool.New = func() any { return NewLogBuilder() }
+	return p
+}
+
+// Get returns a builder without fields.
+func (p *Pool) Get() ILogBuilder {
+	return p.pool.Get().(ILogBuilder)
+}
+
+// Put returns the builder to the pool, the entry compiled by the builder must not be used after it.
+func (p *Pool) Put(b ILogBuilder) {
+	if lb, ok := b.(*logBuilder); ok && len(lb.buf) > maxPooledBufsize {
+		return
+	}
+
+	b.Reset()
+	p.pool.Put(b)
+}
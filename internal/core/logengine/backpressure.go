@@ -0,0 +1,201 @@
+package logengine
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BackpressurePolicy is what AsyncReport does when the reports queue is full.
+type BackpressurePolicy int
+
+const (
+	// BlockTimeout blocks the caller until the queue has room, for up to one second,
+	// and then drops the report.
+	BlockTimeout BackpressurePolicy = iota
+	// Block blocks the caller until the queue has room, the report is never dropped.
+	Block
+	// DropNewest drops the new report.
+	DropNewest
+	// DropOldest drops the oldest queued report to make room for the new one,
+	// as a ring buffer. An oldest report whose level must not be dropped is written at once.
+	DropOldest
+	// SpillToDisk writes the report to a temporary file,
+	// the file is written to the writers once the queue is empty.
+	SpillToDisk
+)
+
+// blockTimeout is the time the BlockTimeout policy blocks the caller.
+const blockTimeout = 1 * time.Second
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case BlockTimeout:
+		return "BLOCK_TIMEOUT"
+	case Block:
+		return "BLOCK"
+	case DropNewest:
+		return "DROP_NEWEST"
+	case DropOldest:
+		return "DROP_OLDEST"
+	case SpillToDisk:
+		return "SPILL_TO_DISK"
+	default:
+		return strconv.Itoa(int(p))
+	}
+}
+
+// droppable reports whether the reports of the policy may be dropped to make room for others.
+func (p BackpressurePolicy) droppable() bool {
+	return p == DropNewest || p == DropOldest
+}
+
+// QueueStats are the metrics of the reports queue.
+type QueueStats struct {
+	Queued        int
+	Capacity      int
+	HighWaterMark int
+	Dropped       map[Level]uint64
+	Spilled       uint64
+}
+
+// backpressure keeps the policies of the levels and the number of dropped reports.
+type backpressure struct {
+	policy  BackpressurePolicy
+	levels  map[Level]BackpressurePolicy
+	dropped map[Level]uint64
+	lock    sync.Mutex
+}
+
+func (b *backpressure) policyFor(level Level) BackpressurePolicy {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if policy, ok := b.levels[level]; ok {
+		return policy
+	}
+	return b.policy
+}
+
+func (b *backpressure) drop(level Level) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.dropped == nil {
+		b.dropped = map[Level]uint64{}
+	}
+	b.dropped[level]++
+}
+
+func (b *backpressure) droppedReports() map[Level]uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return maps.Clone(b.dropped)
+}
+
+// SetBackpressurePolicy sets the policy of all levels,
+// the policy of a level set by SetLevelBackpressurePolicy takes precedence.
+func (l *logger) SetBackpressurePolicy(policy BackpressurePolicy) {
+	l.backpressure.lock.Lock()
+	defer l.backpressure.lock.Unlock()
+	l.backpressure.policy = policy
+}
+
+// SetLevelBackpressurePolicy sets the policy of the level.
+func (l *logger) SetLevelBackpressurePolicy(level Level, policy BackpressurePolicy) {
+	l.backpressure.lock.Lock()
+	defer l.backpressure.lock.Unlock()
+
+	if l.backpressure.levels == nil {
+		l.backpressure.levels = map[Level]BackpressurePolicy{}
+	}
+	l.backpressure.levels[level] = policy
+}
+
+// QueueStats returns the number of queued reports, the capacity and the high-water mark of the queue,
+// the number of dropped reports by level and the number of reports spilled to disk.
+func (l *logger) QueueStats() QueueStats {
+	return QueueStats{
+		Queued:        len(l.reports),
+		Capacity:      cap(l.reports),
+		HighWaterMark: int(l.metrics.highWaterMark.Load()),
+		Dropped:       l.backpressure.droppedReports(),
+		Spilled:       l.spill.spilled(),
+	}
+}
+
+// reportFull applies the policy of the level of the report when the queue is full.
+func (l *logger) reportFull(r ReportType, policy BackpressurePolicy) {
+	switch policy {
+	case Block:
+		select {
+		case l.reports <- r:
+		case <-l.done:
+			l.backpressure.drop(r.Level)
+		}
+
+	case DropNewest:
+		l.backpressure.drop(r.Level)
+
+	case DropOldest:
+		select {
+		case old := <-l.reports:
+			if l.backpressure.policyFor(old.Level).droppable() {
+				l.backpressure.drop(old.Level)
+			} else {
+				l.handleReport(old)
+			}
+		default:
+		}
+
+		select {
+		case l.reports <- r:
+		default:
+			l.backpressure.drop(r.Level)
+		}
+
+	case SpillToDisk:
+		l.spillReport(r)
+
+	default:
+		timer := time.NewTimer(blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case l.reports <- r:
+		case <-timer.C:
+			l.backpressure.drop(r.Level)
+			fmt.Fprintf(os.Stderr, "logger reports channel is full\n")
+		}
+	}
+}
+
+// spillReport writes the report to the spill file.
+func (l *logger) spillReport(r ReportType) {
+	b := l.builders.Get()
+	defer l.builders.Put(b)
+
+	l.reportLock.RLock()
+	entry := l.encode(b, r)
+	l.reportLock.RUnlock()
+
+	if err := l.spill.write(r.Level, entry); err != nil {
+		l.backpressure.drop(r.Level)
+		fmt.Fprintf(os.Stderr, "Failed to spill the report to disk: %v\n", err)
+	}
+}
+
+// drainReports writes the queued reports without waiting for new ones.
+func (l *logger) drainReports() {
+	for {
+		select {
+		case r := <-l.reports:
+			l.handleReport(r)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package logengine
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBackpressurePolicy(t *testing.T) {
+	report := func(level Level, msg string) ReportType {
+		return ReportType{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Level: level, Msg: msg}
+	}
+
+	newLogger := func(t *testing.T, size uint) (*logger, *mockBufferWriter) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+		_l.SetReportQueueSize(size)
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		return _l, buf
+	}
+
+	msgs := func(t *testing.T, buf *mockBufferWriter) []string {
+		var got []string
+		for _, entry := range decodeEntries(t, buf) {
+			got = append(got, entry["msg"].(string))
+		}
+		return got
+	}
+
+	t.Run("should drop the new reports when the queue is full", func(t *testing.T) {
+		l, buf := newLogger(t, 1)
+		l.SetBackpressurePolicy(DropNewest)
+
+		l.AsyncReport(report(Info, "first"))
+		l.AsyncReport(report(Info, "second"))
+		l.AsyncReport(report(Warn, "third"))
+		l.FlushReports()
+
+		if got := msgs(t, buf); len(got) != 1 || got[0] != "first" {
+			t.Errorf("expected only the first report, but got %v", got)
+		}
+
+		stats := l.QueueStats()
+		if stats.Dropped[Info] != 1 || stats.Dropped[Warn] != 1 {
+			t.Errorf("expected one dropped report of info and warn, but got %v", stats.Dropped)
+		}
+	})
+
+	t.Run("should drop the oldest reports when the queue is full", func(t *testing.T) {
+		l, buf := newLogger(t, 2)
+		l.SetBackpressurePolicy(DropOldest)
+
+		for _, msg := range []string{"first", "second", "third", "fourth"} {
+			l.AsyncReport(report(Info, msg))
+		}
+		l.FlushReports()
+
+		got := msgs(t, buf)
+		if len(got) != 2 || got[0] != "third" || got[1] != "fourth" {
+			t.Errorf("expected the newest reports, but got %v", got)
+		}
+		if dropped := l.QueueStats().Dropped[Info]; dropped != 2 {
+			t.Errorf("expected 2 dropped reports, but got %v", dropped)
+		}
+	})
+
+	t.Run("should write the oldest report whose level must not be dropped", func(t *testing.T) {
+		l, buf := newLogger(t, 1)
+		l.SetBackpressurePolicy(DropOldest)
+		l.SetLevelBackpressurePolicy(Error, Block)
+
+		l.AsyncReport(report(Error, "error"))
+		l.AsyncReport(report(Info, "info"))
+		l.FlushReports()
+
+		got := msgs(t, buf)
+		if len(got) != 2 || got[0] != "error" || got[1] != "info" {
+			t.Errorf("expected the error and the info reports, but got %v", got)
+		}
+		if dropped := l.QueueStats().Dropped; len(dropped) != 0 {
+			t.Errorf("expected no dropped report, but got %v", dropped)
+		}
+	})
+
+	t.Run("should spill the reports to disk and replay them in order", func(t *testing.T) {
+		l, buf := newLogger(t, 1)
+		l.SetBackpressurePolicy(SpillToDisk)
+
+		for _, msg := range []string{"first", "second", "third"} {
+			l.AsyncReport(report(Info, msg))
+		}
+
+		if !l.spill.pending() {
+			t.Fatalf("expected spilled reports")
+		}
+		name := l.spill.file.Name()
+
+		l.FlushReports()
+
+		got := msgs(t, buf)
+		if len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+			t.Errorf("expected every report in order, but got %v", got)
+		}
+		if spilled := l.QueueStats().Spilled; spilled != 2 {
+			t.Errorf("expected 2 spilled reports, but got %v", spilled)
+		}
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected the spill file to be removed, but got %v", err)
+		}
+	})
+
+	t.Run("should replay the spilled reports on the reports handler", func(t *testing.T) {
+		l, buf := newLogger(t, 1)
+		l.SetBackpressurePolicy(SpillToDisk)
+
+		l.AsyncReport(report(Info, "first"))
+		l.AsyncReport(report(Info, "second"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			l.HandleReports(ctx)
+			close(done)
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for len(msgs(t, buf)) < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+		<-done
+
+		got := msgs(t, buf)
+		if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+			t.Errorf("expected every report in order, but got %v", got)
+		}
+	})
+
+	t.Run("should release the blocked reports when the logger is closed", func(t *testing.T) {
+		l, _ := newLogger(t, 1)
+		l.SetBackpressurePolicy(Block)
+
+		l.AsyncReport(report(Info, "first"))
+
+		done := make(chan struct{})
+		go func() {
+			l.AsyncReport(report(Info, "second"))
+			close(done)
+		}()
+
+		l.closeReport()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected the blocked report to be released")
+		}
+	})
+}
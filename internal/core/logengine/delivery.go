@@ -0,0 +1,216 @@
+package logengine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// asyncDelivery writes the entries of a writer from its own goroutine,
+// so a slow writer does not stall the others.
+type asyncDelivery struct {
+	entries chan []byte
+	policy  BackpressurePolicy
+
+	// queued are the entries placed in the buffer and done are the entries taken out of it,
+	// written or dropped by DropOldest. An entry is placed and counted under the lock,
+	// so the buffer keeps the order of the count and the flush waits until done reaches queued.
+	// The entries dropped before they are placed are not counted.
+	lock     sync.Mutex
+	cond     *sync.Cond
+	queued   uint64
+	done     uint64
+	stopping bool
+
+	// sending is held by the enqueues, so the entries are not closed while they are sent
+	sending sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+}
+
+// startAsync starts the goroutine which writes the entries of the writer.
+func (i *ionWriter) startAsync(w io.Writer, state *writerState, size int, policy BackpressurePolicy) *asyncDelivery {
+	d := &asyncDelivery{
+		entries: make(chan []byte, size),
+		policy:  policy,
+		stopped: make(chan struct{}),
+	}
+	d.cond = sync.NewCond(&d.lock)
+
+	go i.run(w, state, d)
+	return d
+}
+
+// run writes the entries of the writer until the delivery is closed.
+func (i *ionWriter) run(w io.Writer, state *writerState, d *asyncDelivery) {
+	defer close(d.stopped)
+
+	for entry := range d.entries {
+		cfg := i.config()
+
+		disabled, err := i.deliverRecover(w, state, entry, cfg)
+		if err != nil {
+			if disabled {
+				fmt.Fprintf(os.Stderr, "Disabled the %v target after %v failed writes\n", writerName(w), cfg.breaker.Failures)
+			}
+
+			if cfg.errorHandler != nil {
+				cfg.errorHandler(w, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Failed to write to the %v target, error: %v\n", writerName(w), err)
+			}
+		}
+
+		d.processed(1)
+	}
+}
+
+// deliverRecover delivers the entry from the goroutine of the writer, a panic of the writer
+// is recorded as a failed write, so it does not crash the program.
+func (i *ionWriter) deliverRecover(w io.Writer, state *writerState, entry []byte, cfg writerConfig) (disabled bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("writer panic: %v", r)
+			disabled = state.record(0, err, i.now(), cfg.breaker)
+		}
+	}()
+
+	return i.deliverLocked(w, state, entry, cfg)
+}
+
+// enqueue queues a copy of the entry, the backpressure policy is applied when the buffer is full.
+// It returns the number of dropped entries, and false when the delivery is closed,
+// so the entry must be written from the caller. The writeLock must not be held,
+// so the blocking policies only hold the caller of this writer.
+func (d *asyncDelivery) enqueue(entry []byte) (int, bool) {
+	d.sending.RLock()
+	defer d.sending.RUnlock()
+
+	if d.closed {
+		return 0, false
+	}
+
+	entry = bytes.Clone(entry)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.place(entry) {
+		return 0, true
+	}
+
+	switch d.policy {
+	case DropNewest:
+		return 1, true
+
+	case DropOldest:
+		dropped := 0
+		select {
+		case <-d.entries:
+			d.taken(1)
+			dropped++
+		default:
+		}
+
+		if !d.place(entry) {
+			dropped++
+		}
+		return dropped, true
+
+	case Block, SpillToDisk:
+		return d.wait(entry, nil)
+
+	default:
+		timedOut := false
+		timer := time.AfterFunc(blockTimeout, func() {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			timedOut = true
+			d.cond.Broadcast()
+		})
+		defer timer.Stop()
+
+		return d.wait(entry, &timedOut)
+	}
+}
+
+// place places the entry in the buffer when it has room, and counts it. The lock must be held.
+func (d *asyncDelivery) place(entry []byte) bool {
+	select {
+	case d.entries <- entry:
+		d.queued++
+		return true
+	default:
+		return false
+	}
+}
+
+// wait waits for the room of the entry in the buffer, until the delivery is closed
+// or the timeout is set. The lock must be held, it is released while waiting.
+func (d *asyncDelivery) wait(entry []byte, timedOut *bool) (int, bool) {
+	for {
+		if d.place(entry) {
+			return 0, true
+		}
+		if d.stopping {
+			return 0, false
+		}
+		if timedOut != nil && *timedOut {
+			return 1, true
+		}
+		d.cond.Wait()
+	}
+}
+
+// taken records the entries taken out of the buffer. The lock must be held.
+func (d *asyncDelivery) taken(n uint64) {
+	d.done += n
+	d.cond.Broadcast()
+}
+
+// processed records the entries written by the goroutine of the writer.
+func (d *asyncDelivery) processed(n uint64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.taken(n)
+}
+
+// flush waits until the entries placed in the buffer before the call are written or dropped.
+func (d *asyncDelivery) flush() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for queued := d.queued; d.done < queued; {
+		d.cond.Wait()
+	}
+}
+
+// close writes the queued entries and stops the goroutine, the blocked enqueues
+// give their entries back to the caller. The writeLock must be held.
+func (d *asyncDelivery) close() {
+	d.lock.Lock()
+	d.stopping = true
+	d.cond.Broadcast()
+	d.lock.Unlock()
+
+	d.sending.Lock()
+	d.closed = true
+	close(d.entries)
+	d.sending.Unlock()
+
+	<-d.stopped
+}
+
+// stopAsync writes the buffered entries of the writer and stops its goroutine,
+// the next entries are written from the caller. The writeLock must be held.
+func (s *writerState) stopAsync() {
+	if s.async == nil {
+		return
+	}
+
+	s.async.close()
+	s.async = nil
+}
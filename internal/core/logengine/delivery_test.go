@@ -0,0 +1,287 @@
+package logengine
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks every write until it is released.
+type blockingWriter struct {
+	release chan struct{}
+	lock    sync.Mutex
+	buf     bytes.Buffer
+	syncs   int
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{release: make(chan struct{})}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *blockingWriter) Sync() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.syncs++
+	return nil
+}
+
+func (b *blockingWriter) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncDelivery(t *testing.T) {
+	t.Run("should not stall the other writers on a slow writer", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		slow := newBlockingWriter()
+		fast := &mockBufferWriter{}
+
+		w.SetWriterOptions(slow, WriterOptions{Buffer: 10})
+		w.AddWriter(slow, fast)
+		defer w.Close()
+
+		done := make(chan struct{})
+		go func() {
+			_ = w.WriteEntry(Info, []byte("first\n"))
+			_ = w.WriteEntry(Info, []byte("second\n"))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected the writes not to wait for the slow writer")
+		}
+
+		if fast.String() != "first\nsecond\n" {
+			t.Errorf("expected the entries on the fast writer, but got %q", fast.String())
+		}
+
+		close(slow.release)
+		w.Flush()
+
+		if slow.String() != "first\nsecond\n" {
+			t.Errorf("expected the entries on the slow writer after the flush, but got %q", slow.String())
+		}
+	})
+
+	t.Run("should not stall the other writers on a blocked writer with a full buffer", func(t *testing.T) {
+		for _, policy := range []BackpressurePolicy{Block, BlockTimeout} {
+			w := NewWriter().(*ionWriter)
+			blocked := newBlockingWriter()
+			fast := &mockBufferWriter{}
+
+			w.SetWriterOptions(blocked, WriterOptions{Buffer: 1, Policy: policy})
+			w.AddWriter(blocked, fast)
+
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_ = w.WriteEntry(Info, []byte("entry\n"))
+				}()
+			}
+
+			deadline := time.Now().Add(500 * time.Millisecond)
+			for fast.String() != strings.Repeat("entry\n", 10) && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if got := strings.Count(fast.String(), "entry\n"); got != 10 {
+				t.Errorf("expected the fast writer to get the 10 entries with %v, but got %v", policy, got)
+			}
+
+			close(blocked.release)
+			wg.Wait()
+			w.Close()
+		}
+	})
+
+	t.Run("should give the blocked entries back to the caller on the close", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		blocked := newBlockingWriter()
+
+		w.SetWriterOptions(blocked, WriterOptions{Buffer: 1, Policy: Block})
+		w.AddWriter(blocked)
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = w.WriteEntry(Info, []byte("entry\n"))
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		close(blocked.release)
+		w.Close()
+		wg.Wait()
+
+		if got := strings.Count(blocked.String(), "entry\n"); got != 3 {
+			t.Errorf("expected the 3 entries, but got %v", got)
+		}
+	})
+
+	t.Run("should drop the entries when the buffer is full", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		slow := newBlockingWriter()
+
+		w.SetWriterOptions(slow, WriterOptions{Buffer: 1, Policy: DropNewest})
+		w.AddWriter(slow)
+
+		// the goroutine holds one entry and the buffer holds another
+		for range 5 {
+			_ = w.WriteEntry(Info, []byte("entry\n"))
+		}
+
+		close(slow.release)
+		w.Flush()
+
+		stats := w.Stats()[0]
+		if stats.Dropped == 0 || stats.Dropped > 4 {
+			t.Errorf("expected the entries above the buffer to be dropped, but got %+v", stats)
+		}
+		if written := bytes.Count([]byte(slow.String()), []byte("\n")); uint64(written)+stats.Dropped != 5 {
+			t.Errorf("expected every entry to be written or dropped, but got %v written and %v dropped", written, stats.Dropped)
+		}
+
+		w.Close()
+	})
+
+	t.Run("should wait on the flush for the buffered entries whatever the next drops", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		slow := newBlockingWriter()
+
+		w.SetWriterOptions(slow, WriterOptions{Buffer: 1, Policy: DropNewest})
+		w.AddWriter(slow)
+		delivery := w.states[slow].async
+
+		// the goroutine holds the first entry and the buffer holds the second
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		for len(delivery.entries) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+
+		flushed := make(chan struct{})
+		go func() {
+			w.Flush()
+			close(flushed)
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		// the next entries are dropped while the flush waits
+		_ = w.WriteEntry(Info, []byte("dropped\n"))
+		_ = w.WriteEntry(Info, []byte("dropped\n"))
+
+		select {
+		case <-flushed:
+			t.Fatalf("expected the flush to wait for the buffered entries, but got %q written", slow.String())
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(slow.release)
+		<-flushed
+
+		if slow.String() != "entry\nentry\n" {
+			t.Errorf("expected the 2 buffered entries written on the flush, but got %q", slow.String())
+		}
+		if stats := w.Stats()[0]; stats.Dropped != 2 {
+			t.Errorf("expected 2 dropped entries, but got %+v", stats)
+		}
+
+		w.Close()
+	})
+
+	t.Run("should record the panic of a writer as a failed write", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		var failures []error
+		w.SetErrorHandler(func(_ io.Writer, err error) { failures = append(failures, err) })
+
+		panicking := &MockWriter{WriteFunc: func(p []byte) (int, error) { panic("broken writer") }}
+		w.SetWriterOptions(panicking, WriterOptions{Buffer: 10})
+		w.AddWriter(panicking)
+
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		w.Close()
+
+		if len(failures) != 2 || !strings.Contains(failures[0].Error(), "broken writer") {
+			t.Errorf("expected the 2 panics reported as errors, but got %v", failures)
+		}
+		if stats := w.Stats()[0]; stats.Errors != 2 || stats.State != WriterFailing {
+			t.Errorf("expected the panics recorded as failed writes, but got %+v", stats)
+		}
+	})
+
+	t.Run("should write the buffered entries before the sync", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		slow := newBlockingWriter()
+		close(slow.release)
+
+		w.SetWriterOptions(slow, WriterOptions{Buffer: 10})
+		w.AddWriter(slow)
+		defer w.Close()
+
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		w.Sync()
+
+		if slow.String() != "entry\n" || slow.syncs != 1 {
+			t.Errorf("expected the entry written and synced, but got %q and %v syncs", slow.String(), slow.syncs)
+		}
+	})
+
+	t.Run("should write from the caller after the close", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		buf := &mockBufferWriter{}
+
+		w.SetWriterOptions(buf, WriterOptions{Buffer: 10})
+		w.AddWriter(buf)
+
+		_ = w.WriteEntry(Info, []byte("buffered\n"))
+		w.Close()
+
+		if w.states[buf].async != nil {
+			t.Fatalf("expected the delivery to be stopped")
+		}
+
+		_ = w.WriteEntry(Info, []byte("direct\n"))
+		if buf.String() != "buffered\ndirect\n" {
+			t.Errorf("expected every entry, but got %q", buf.String())
+		}
+	})
+
+	t.Run("should stop the delivery of a deleted writer", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		buf := &mockBufferWriter{}
+
+		w.SetWriterOptions(buf, WriterOptions{Buffer: 10})
+		w.AddWriter(buf)
+		delivery := w.states[buf].async
+
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		w.DeleteWriter(buf)
+
+		select {
+		case <-delivery.stopped:
+		default:
+			t.Errorf("expected the goroutine of the writer to be stopped")
+		}
+		if buf.String() != "entry\n" {
+			t.Errorf("expected the buffered entry to be written, but got %q", buf.String())
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package logengine
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// duplicateKey identifies the reports which are duplicates of each other:
+// the same level and message logged by the same caller.
+type duplicateKey struct {
+	level    Level
+	msg      string
+	file     string
+	pkg      string
+	function string
+	line     int
+}
+
+func newDuplicateKey(r ReportType) duplicateKey {
+	return duplicateKey{
+		level:    r.Level,
+		msg:      r.Msg,
+		file:     r.CallerInfo.File,
+		pkg:      r.CallerInfo.Package,
+		function: r.CallerInfo.Function,
+		line:     r.CallerInfo.Line,
+	}
+}
+
+// duplicates collapses the runs of duplicated reports, as the repeated messages of syslog:
+// the first report is written, the repeats are held and written as one summary report
+// on the next different report or after the interval.
+type duplicates struct {
+	interval time.Duration
+
+	last      duplicateKey
+	hasLast   bool
+	held      ReportType
+	count     int
+	firstTime time.Time
+	timer     *time.Timer
+
+	lock sync.Mutex
+}
+
+// handleReport writes the report, or holds it when it repeats the last report.
+func (l *logger) handleReport(r ReportType) {
+	d := &l.duplicates
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.interval <= 0 {
+		l.writeHeldDuplicates()
+		d.hasLast = false
+		l.write(r)
+		return
+	}
+
+	key := newDuplicateKey(r)
+	if d.hasLast && key == d.last {
+		if d.count == 0 {
+			d.firstTime = r.Time
+			d.timer = time.AfterFunc(d.interval, l.FlushDuplicates)
+		}
+		d.held = r
+		d.count++
+		return
+	}
+
+	l.writeHeldDuplicates()
+	d.last = key
+	d.hasLast = true
+	l.write(r)
+}
+
+// FlushDuplicates writes the summary of the held duplicated reports.
+func (l *logger) FlushDuplicates() {
+	l.duplicates.lock.Lock()
+	defer l.duplicates.lock.Unlock()
+	l.writeHeldDuplicates()
+}
+
+// writeHeldDuplicates writes the held reports, a single report is written as it is,
+// more reports are written as the last one with the number of reports and the time of the first and last ones.
+// The lock of the duplicates must be held.
+func (l *logger) writeHeldDuplicates() {
+	d := &l.duplicates
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.count == 0 {
+		return
+	}
+
+	summary := d.held
+	if d.count > 1 {
+		format := l.TimeFormat()
+		summary.Fields = slices.Concat(summary.Fields, []Field{
+			{Key: "repeat_count", Value: d.count},
+			{Key: "first_time", Value: format.value(d.firstTime)},
+			{Key: "last_time", Value: format.value(d.held.Time)},
+		})
+	}
+
+	d.held = ReportType{}
+	d.count = 0
+	l.write(summary)
+}
+
+// SetDuplicateSuppression sets the interval the duplicated reports are held before
+// their summary is written, zero or less disables the suppression.
+func (l *logger) SetDuplicateSuppression(interval time.Duration) {
+	l.duplicates.lock.Lock()
+	defer l.duplicates.lock.Unlock()
+
+	if interval <= 0 {
+		l.writeHeldDuplicates()
+		l.duplicates.hasLast = false
+	}
+	l.duplicates.interval = interval
+}
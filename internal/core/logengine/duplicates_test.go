@@ -0,0 +1,167 @@
+package logengine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+// decodeEntries decodes the log lines written on the buffer.
+func decodeEntries(t *testing.T, buf *mockBufferWriter) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected a valid JSON line, but got %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestHandleReportDuplicates(t *testing.T) {
+	ci := runtimeinfo.CallerInfo{File: "main.go", Package: "main", Function: "main", Line: 10}
+	report := func(msg string, second int) ReportType {
+		return ReportType{
+			Time:       time.Date(2025, 1, 1, 0, 0, second, 0, time.UTC),
+			Level:      Warn,
+			Msg:        msg,
+			CallerInfo: ci,
+		}
+	}
+
+	t.Run("should write every report when the suppression is disabled", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+
+		for i := range 3 {
+			_l.handleReport(report("disk full", i))
+		}
+
+		if entries := decodeEntries(t, buf); len(entries) != 3 {
+			t.Errorf("expected 3 entries, but got %v", entries)
+		}
+	})
+
+	t.Run("should collapse the repeats on the next different report", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		l.SetDuplicateSuppression(time.Hour)
+
+		for i := range 4 {
+			_l.handleReport(report("disk full", i))
+		}
+		_l.handleReport(report("disk ok", 5))
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 3 {
+			t.Fatalf("expected the first, the summary and the different entries, but got %v", entries)
+		}
+
+		if _, ok := entries[0]["repeat_count"]; ok || entries[0]["msg"] != "disk full" {
+			t.Errorf("expected the first entry as it is, but got %v", entries[0])
+		}
+
+		summary := entries[1]
+		if summary["msg"] != "disk full" || summary["repeat_count"] != float64(3) ||
+			summary["first_time"] != "2025-01-01T00:00:01Z" || summary["last_time"] != "2025-01-01T00:00:03Z" {
+			t.Errorf("expected the summary of the repeats, but got %v", summary)
+		}
+
+		if entries[2]["msg"] != "disk ok" {
+			t.Errorf("expected the different entry, but got %v", entries[2])
+		}
+	})
+
+	t.Run("should write a single repeat as it is", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		l.SetDuplicateSuppression(time.Hour)
+
+		_l.handleReport(report("disk full", 0))
+		_l.handleReport(report("disk full", 1))
+		l.FlushReports()
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+		if _, ok := entries[1]["repeat_count"]; ok {
+			t.Errorf("expected the repeat without summary, but got %v", entries[1])
+		}
+	})
+
+	t.Run("should write the summary after the interval", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		l.SetDuplicateSuppression(10 * time.Millisecond)
+
+		for i := range 3 {
+			_l.handleReport(report("disk full", i))
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for len(decodeEntries(t, buf)) < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 2 || entries[1]["repeat_count"] != float64(2) {
+			t.Errorf("expected the first entry and the summary, but got %v", entries)
+		}
+	})
+
+	t.Run("should write the held repeats when the suppression is disabled", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		l.SetDuplicateSuppression(time.Hour)
+
+		for i := range 3 {
+			_l.handleReport(report("disk full", i))
+		}
+		l.SetDuplicateSuppression(0)
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 2 || entries[1]["repeat_count"] != float64(2) {
+			t.Errorf("expected the first entry and the summary, but got %v", entries)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package logengine
+
+import "fmt"
+
+// maxErrorChain limits the errors recorded in the chain of an error,
+// it protects the log from errors which unwrap into themselves.
+const maxErrorChain = 32
+
+// ErrorValue is the value of a field which records an error.
+// The report writes the message of the error under the key of the field,
+// its concrete type under "<key>_type" and its unwrap chain under "<key>_chain".
+type ErrorValue struct {
+	Err error
+}
+
+// errorType returns the name of the concrete type of the error.
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// errorChain returns the message and type of the error and of every error it wraps,
+// following errors.Unwrap and errors.Join in depth-first order.
+func errorChain(err error) []any {
+	var chain []any
+
+	var walk func(err error)
+	walk = func(err error) {
+		if err == nil || len(chain) >= maxErrorChain {
+			return
+		}
+
+		chain = append(chain, map[string]any{
+			"message": err.Error(),
+			"type":    errorType(err),
+		})
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	walk(err)
+
+	return chain
+}
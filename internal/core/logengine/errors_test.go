@@ -0,0 +1,56 @@
+package logengine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// selfError unwraps into itself
+type selfError struct{}
+
+func (e *selfError) Error() string { return "self" }
+func (e *selfError) Unwrap() error { return e }
+
+func TestErrorChain(t *testing.T) {
+	t.Run("should follow the wrapped and joined errors in depth-first order", func(t *testing.T) {
+		errA := errors.New("a")
+		errB := errors.New("b")
+		err := fmt.Errorf("top: %w", errors.Join(fmt.Errorf("wrap a: %w", errA), errB))
+
+		var messages []string
+		for _, e := range errorChain(err) {
+			messages = append(messages, e.(map[string]any)["message"].(string))
+		}
+
+		expected := []string{"top: wrap a: a\nb", "wrap a: a\nb", "wrap a: a", "a", "b"}
+		if !reflect.DeepEqual(messages, expected) {
+			t.Errorf("expected the chain %q, but got %q", expected, messages)
+		}
+	})
+
+	t.Run("should record the concrete type of the errors", func(t *testing.T) {
+		chain := errorChain(fmt.Errorf("wrap: %w", errors.New("inner")))
+
+		expected := []any{
+			map[string]any{"message": "wrap: inner", "type": "*fmt.wrapError"},
+			map[string]any{"message": "inner", "type": "*errors.errorString"},
+		}
+		if !reflect.DeepEqual(chain, expected) {
+			t.Errorf("expected the chain %v, but got %v", expected, chain)
+		}
+	})
+
+	t.Run("should limit the chain of an error which unwraps into itself", func(t *testing.T) {
+		if chain := errorChain(&selfError{}); len(chain) != maxErrorChain {
+			t.Errorf("expected the chain to have %d errors, but got %d", maxErrorChain, len(chain))
+		}
+	})
+
+	t.Run("should return an empty chain for a nil error", func(t *testing.T) {
+		if chain := errorChain(nil); chain != nil {
+			t.Errorf("expected no chain, but got %v", chain)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package logengine
+
+// badKey is used as the key of a value that has no valid key,
+// it follows the same convention used by log/slog.
+const badKey = "!BADKEY"
+
+// Field is a key/value pair attached to a single log entry.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// ToFields converts a list of alternating keys and values into fields.
+// A Field in the list is used as it is. A value without a string key
+// is recorded under the "!BADKEY" key.
+func ToFields(keysAndValues ...any) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch k := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, k)
+
+		case string:
+			if i+1 >= len(keysAndValues) {
+				fields = append(fields, Field{Key: badKey, Value: k})
+				continue
+			}
+			fields = append(fields, Field{Key: k, Value: keysAndValues[i+1]})
+			i++
+
+		default:
+			fields = append(fields, Field{Key: badKey, Value: k})
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,49 @@
+package logengine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToFields(t *testing.T) {
+	testCases := [...]struct {
+		name           string
+		keysAndValues  []any
+		expectedFields []Field
+	}{
+		{
+			name:           "should return nil when no keys and values are given",
+			keysAndValues:  nil,
+			expectedFields: nil,
+		},
+		{
+			name:           "should pair keys and values",
+			keysAndValues:  []any{"patient_session", "abc", "attempt", 2},
+			expectedFields: []Field{{Key: "patient_session", Value: "abc"}, {Key: "attempt", Value: 2}},
+		},
+		{
+			name:           "should keep a field as it is",
+			keysAndValues:  []any{Field{Key: "id", Value: 1}, "key", "value"},
+			expectedFields: []Field{{Key: "id", Value: 1}, {Key: "key", Value: "value"}},
+		},
+		{
+			name:           "should use bad key when the last key has no value",
+			keysAndValues:  []any{"key", "value", "dangling"},
+			expectedFields: []Field{{Key: "key", Value: "value"}, {Key: badKey, Value: "dangling"}},
+		},
+		{
+			name:           "should use bad key when the key is not a string",
+			keysAndValues:  []any{42, "key", "value"},
+			expectedFields: []Field{{Key: badKey, Value: 42}, {Key: "key", Value: "value"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := ToFields(tt.keysAndValues...)
+			if !reflect.DeepEqual(fields, tt.expectedFields) {
+				t.Errorf("expected fields to be %v, but got %v", tt.expectedFields, fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package logengine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+// levelRule sets the lowest level written for the callers matching the pattern.
+// The pattern is a glob, as in path.Match, with the form "package" or "package.Function",
+// the package is matched against the import path of the package of the caller or its last elements,
+// and the function against the function name of the caller.
+type levelRule struct {
+	pattern     string
+	pkgPattern  string
+	funcPattern string
+	level       Level
+	specificity int
+}
+
+// levelRules is an immutable set of rules sorted from the most to the least specific,
+// a rule for functions is more specific than a rule for packages,
+// and between them the rule with more characters which are not wildcards is more specific.
+// A new set is created on every change so it can be read without locks.
+type levelRules struct {
+	rules  []levelRule
+	lowest Level
+}
+
+func newLevelRule(pattern string, level Level) (levelRule, error) {
+	r := levelRule{pattern: pattern, pkgPattern: pattern, level: level}
+
+	// the function starts at the first dot after the last slash, as in the function names of the runtime
+	lastSlash := strings.LastIndexByte(pattern, '/')
+	if i := strings.IndexByte(pattern[lastSlash+1:], '.'); i >= 0 {
+		r.pkgPattern = pattern[:lastSlash+1+i]
+		r.funcPattern = pattern[lastSlash+1+i+1:]
+	}
+
+	if _, err := path.Match(r.pkgPattern, ""); err != nil {
+		return levelRule{}, err
+	}
+	if _, err := path.Match(r.funcPattern, ""); err != nil {
+		return levelRule{}, err
+	}
+
+	r.specificity = literalLen(r.pkgPattern) + literalLen(r.funcPattern)
+
+	return r, nil
+}
+
+// literalLen returns the number of characters of the pattern which are not wildcards.
+func literalLen(pattern string) int {
+	n := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '*' || c == '?' || inClass:
+		case c == '\\':
+			i++
+			n++
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+func (r levelRule) match(ci runtimeinfo.CallerInfo) bool {
+	if !matchPackage(r.pkgPattern, ci.PackagePath) {
+		return false
+	}
+	if r.funcPattern == "" {
+		return true
+	}
+	ok, _ := path.Match(r.funcPattern, ci.Function)
+	return ok
+}
+
+// matchPackage reports whether the pattern matches the import path of the package or its last elements,
+// so "billing" and "internal/billing" match "example.com/service/internal/billing".
+func matchPackage(pattern string, pkgPath string) bool {
+	for {
+		if ok, _ := path.Match(pattern, pkgPath); ok {
+			return true
+		}
+
+		i := strings.IndexByte(pkgPath, '/')
+		if i < 0 {
+			return false
+		}
+		pkgPath = pkgPath[i+1:]
+	}
+}
+
+// with returns a copy of the rules with the given rules added or replaced.
+func (lr *levelRules) with(rules map[string]Level) *levelRules {
+	newRules := lr.copyRules()
+
+	for pattern, level := range rules {
+		r, err := newLevelRule(pattern, level)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "level rule %q is invalid: %v\n", pattern, err)
+			continue
+		}
+
+		newRules = slices.DeleteFunc(newRules, func(old levelRule) bool {
+			return old.pattern == pattern
+		})
+		newRules = append(newRules, r)
+	}
+
+	return newLevelRules(newRules)
+}
+
+// without returns a copy of the rules without the rules of the given patterns.
+func (lr *levelRules) without(patterns ...string) *levelRules {
+	newRules := slices.DeleteFunc(lr.copyRules(), func(r levelRule) bool {
+		return slices.Contains(patterns, r.pattern)
+	})
+	return newLevelRules(newRules)
+}
+
+func (lr *levelRules) copyRules() []levelRule {
+	if lr == nil {
+		return nil
+	}
+	return slices.Clone(lr.rules)
+}
+
+func newLevelRules(rules []levelRule) *levelRules {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(rules, func(a, b levelRule) int {
+		// a rule for functions is always more specific than a rule for packages
+		if aFunc, bFunc := a.funcPattern != "", b.funcPattern != ""; aFunc != bFunc {
+			if aFunc {
+				return -1
+			}
+			return 1
+		}
+		if a.specificity != b.specificity {
+			return b.specificity - a.specificity
+		}
+		return strings.Compare(a.pattern, b.pattern)
+	})
+
+	lowest := rules[0].level
+	for _, r := range rules[1:] {
+		lowest = min(lowest, r.level)
+	}
+
+	return &levelRules{rules: rules, lowest: lowest}
+}
+
+// levelFor returns the level of the most specific rule matching the caller,
+// it returns false when no rule matches.
+func (lr *levelRules) levelFor(ci runtimeinfo.CallerInfo) (Level, bool) {
+	if lr == nil {
+		return 0, false
+	}
+	for _, r := range lr.rules {
+		if r.match(ci) {
+			return r.level, true
+		}
+	}
+	return 0, false
+}
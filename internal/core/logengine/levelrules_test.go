@@ -0,0 +1,251 @@
+package logengine
+
+import (
+	"testing"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+func TestNewLevelRule(t *testing.T) {
+	t.Run("should split the package and the function patterns", func(t *testing.T) {
+		r, err := newLevelRule("billing.(*Invoice).*", Debug)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if r.pkgPattern != "billing" || r.funcPattern != "(*Invoice).*" {
+			t.Errorf("expected the patterns to be %q and %q, but got %q and %q", "billing", "(*Invoice).*", r.pkgPattern, r.funcPattern)
+		}
+	})
+
+	t.Run("should split the function after the import path", func(t *testing.T) {
+		r, err := newLevelRule("github.com/acme/service/internal/billing.Charge*", Debug)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if r.pkgPattern != "github.com/acme/service/internal/billing" || r.funcPattern != "Charge*" {
+			t.Errorf("expected the patterns to be the import path and %q, but got %q and %q", "Charge*", r.pkgPattern, r.funcPattern)
+		}
+	})
+
+	t.Run("should keep the import path without function", func(t *testing.T) {
+		r, err := newLevelRule("github.com/acme/service/internal/billing", Debug)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if r.pkgPattern != "github.com/acme/service/internal/billing" || r.funcPattern != "" {
+			t.Errorf("expected only the package pattern, but got %q and %q", r.pkgPattern, r.funcPattern)
+		}
+	})
+
+	t.Run("should return an error when the pattern is invalid", func(t *testing.T) {
+		if _, err := newLevelRule("billing[", Debug); err == nil {
+			t.Error("expected an error for the invalid pattern, but got nil")
+		}
+	})
+}
+
+func TestLiteralLen(t *testing.T) {
+	testCases := [...]struct {
+		pattern  string
+		expected int
+	}{
+		{pattern: "billing", expected: 7},
+		{pattern: "bill*", expected: 4},
+		{pattern: "b?ll[ai]ng", expected: 5},
+		{pattern: `a\*`, expected: 2},
+		{pattern: "*", expected: 0},
+	}
+
+	for _, tt := range testCases {
+		if n := literalLen(tt.pattern); n != tt.expected {
+			t.Errorf("expected the literal length of %q to be %d, but got %d", tt.pattern, tt.expected, n)
+		}
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	rules := (*levelRules)(nil).with(map[string]Level{
+		"*":               Error,
+		"bill*":           Info,
+		"billing":         Debug,
+		"billing.Charge*": Warn,
+		"billing.*":       Trace,
+	})
+
+	testCases := [...]struct {
+		name     string
+		caller   runtimeinfo.CallerInfo
+		expected Level
+		ok       bool
+	}{
+		{
+			name:     "function rule wins over package rules",
+			caller:   runtimeinfo.CallerInfo{Package: "billing", PackagePath: "example.com/billing", Function: "ChargeCard"},
+			expected: Warn,
+			ok:       true,
+		},
+		{
+			name:     "less specific function rule",
+			caller:   runtimeinfo.CallerInfo{Package: "billing", PackagePath: "example.com/billing", Function: "Refund"},
+			expected: Trace,
+			ok:       true,
+		},
+		{
+			name:     "package rule with more literal characters wins",
+			caller:   runtimeinfo.CallerInfo{Package: "billboard", PackagePath: "example.com/billboard", Function: "Show"},
+			expected: Info,
+			ok:       true,
+		},
+		{
+			name:     "wildcard rule",
+			caller:   runtimeinfo.CallerInfo{Package: "main", PackagePath: "main", Function: "main"},
+			expected: Error,
+			ok:       true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			level, ok := rules.levelFor(tt.caller)
+			if ok != tt.ok || level != tt.expected {
+				t.Errorf("expected level to be %v (%v), but got %v (%v)", tt.expected, tt.ok, level, ok)
+			}
+		})
+	}
+
+	t.Run("should not match without rules", func(t *testing.T) {
+		var empty *levelRules
+		if _, ok := empty.levelFor(runtimeinfo.CallerInfo{Package: "main"}); ok {
+			t.Error("expected no rule to match")
+		}
+	})
+}
+
+func TestLevelForImportPath(t *testing.T) {
+	internalBilling := runtimeinfo.CallerInfo{Package: "billing", PackagePath: "github.com/acme/service/internal/billing", Function: "Charge"}
+	vendorBilling := runtimeinfo.CallerInfo{Package: "billing", PackagePath: "github.com/vendor/sdk/billing", Function: "Charge"}
+
+	testCases := [...]struct {
+		name    string
+		pattern string
+		caller  runtimeinfo.CallerInfo
+		ok      bool
+	}{
+		{name: "last elements of the import path", pattern: "internal/billing", caller: internalBilling, ok: true},
+		{name: "last elements of another package with the same name", pattern: "internal/billing", caller: vendorBilling, ok: false},
+		{name: "package name", pattern: "billing", caller: vendorBilling, ok: true},
+		{name: "full import path", pattern: "github.com/acme/service/internal/billing", caller: internalBilling, ok: true},
+		{name: "full import path of another package", pattern: "github.com/acme/service/internal/billing", caller: vendorBilling, ok: false},
+		{name: "full import path with function", pattern: "github.com/vendor/sdk/billing.Charge", caller: vendorBilling, ok: true},
+		{name: "wildcard element", pattern: "github.com/acme/*/internal/*", caller: internalBilling, ok: true},
+		{name: "part of an element", pattern: "service/internal/bill", caller: internalBilling, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := (*levelRules)(nil).with(map[string]Level{tt.pattern: Debug})
+			if _, ok := rules.levelFor(tt.caller); ok != tt.ok {
+				t.Errorf("expected the match of %q on %q to be %v, but got %v", tt.pattern, tt.caller.PackagePath, tt.ok, ok)
+			}
+		})
+	}
+}
+
+func TestLevelRulesChanges(t *testing.T) {
+	t.Run("should replace the rule of the same pattern", func(t *testing.T) {
+		rules := (*levelRules)(nil).with(map[string]Level{"billing": Debug})
+		rules = rules.with(map[string]Level{"billing": Error})
+
+		if len(rules.rules) != 1 || rules.rules[0].level != Error {
+			t.Errorf("expected only the replaced rule, but got %+v", rules.rules)
+		}
+	})
+
+	t.Run("should keep the lowest level of the rules", func(t *testing.T) {
+		rules := (*levelRules)(nil).with(map[string]Level{"a": Warn, "b": Debug})
+
+		if rules.lowest != Debug {
+			t.Errorf("expected the lowest level to be %v, but got %v", Debug, rules.lowest)
+		}
+	})
+
+	t.Run("should delete the rules and become nil when empty", func(t *testing.T) {
+		rules := (*levelRules)(nil).with(map[string]Level{"a": Warn, "b": Debug})
+
+		rules = rules.without("b")
+		if len(rules.rules) != 1 || rules.lowest != Warn {
+			t.Errorf("expected only the rule %q, but got %+v", "a", rules.rules)
+		}
+
+		if rules = rules.without("a"); rules != nil {
+			t.Errorf("expected no rules, but got %+v", rules)
+		}
+	})
+
+	t.Run("should not change the previous set", func(t *testing.T) {
+		rules := (*levelRules)(nil).with(map[string]Level{"a": Warn})
+		_ = rules.with(map[string]Level{"b": Debug})
+
+		if len(rules.rules) != 1 {
+			t.Errorf("expected the previous set to be unchanged, but got %+v", rules.rules)
+		}
+	})
+}
+
+func TestEnabledFor(t *testing.T) {
+	billing := runtimeinfo.CallerInfo{Package: "billing", PackagePath: "example.com/service/billing", Function: "Charge"}
+	other := runtimeinfo.CallerInfo{Package: "main", PackagePath: "main", Function: "main"}
+
+	t.Run("should use the rule level for the matching callers", func(t *testing.T) {
+		l := NewLogger()
+		l.SetMinLevel(Warn)
+		l.AddLevelRules(map[string]Level{"billing": Debug})
+
+		if !l.HasLevelRules() {
+			t.Error("expected the logger to have level rules")
+		}
+		if !l.Enabled(Debug) {
+			t.Error("expected debug to be possibly enabled")
+		}
+		if !l.EnabledFor(Debug, billing) {
+			t.Error("expected debug to be enabled for billing")
+		}
+		if l.EnabledFor(Debug, other) {
+			t.Error("expected debug to be disabled for other packages")
+		}
+		if !l.EnabledFor(Warn, other) {
+			t.Error("expected warn to be enabled for other packages")
+		}
+	})
+
+	t.Run("should change the rules at runtime", func(t *testing.T) {
+		l := NewLogger()
+		l.SetMinLevel(Warn)
+		l.AddLevelRules(map[string]Level{"billing": Debug})
+		l.DeleteLevelRules("billing")
+
+		if l.HasLevelRules() {
+			t.Error("expected the logger to have no level rules")
+		}
+		if l.Enabled(Debug) || l.EnabledFor(Debug, billing) {
+			t.Error("expected debug to be disabled after the rule is deleted")
+		}
+	})
+
+	t.Run("should require the trace mode for trace", func(t *testing.T) {
+		l := NewLogger()
+		l.AddLevelRules(map[string]Level{"billing": Trace})
+
+		if l.EnabledFor(Trace, billing) {
+			t.Error("expected trace to be disabled without trace mode")
+		}
+
+		l.SetTraceMode(true)
+		if !l.EnabledFor(Trace, billing) {
+			t.Error("expected trace to be enabled with trace mode")
+		}
+	})
+}
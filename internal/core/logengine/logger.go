@@ -2,12 +2,11 @@ package logengine
 
 import (
 	"context"
-	"fmt"
 	"maps"
-	"os"
+	"math"
 	"slices"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IonicHealthUsa/ionlog/internal/core/logbuilder"
@@ -16,14 +15,22 @@ import (
 )
 
 type ReportType struct {
-	Time       string
+	Time       time.Time
 	Level      Level
 	Msg        string
 	CallerInfo runtimeinfo.CallerInfo
+	Fields     []Field
+	Stack      []runtimeinfo.StackFrame
 }
 
+// defaultMemoryMaxRecords is the default max number of records of the log once memory.
+const defaultMemoryMaxRecords = 10000
+
+// noStackTrace is the stack trace level which disables the stack traces.
+const noStackTrace = math.MaxInt32
+
 type logger struct {
-	builder    logbuilder.ILogBuilder
+	builders   *logbuilder.Pool
 	logsMemory memory.IRecordMemory
 	closed     bool
 	reports    chan ReportType
@@ -31,8 +38,24 @@ type logger struct {
 
 	staticFields map[string]string
 	traceMode    bool
+	timeFormat   TimeFormat
+	minLevel     atomic.Int32
+
+	stackTraceLevel atomic.Int32
+
+	sampler     atomic.Pointer[sampler]
+	samplerLock sync.Mutex
+
+	duplicates   duplicates
+	backpressure backpressure
+	metrics      metrics
+	spill        *spill
+	done         chan struct{}
 
-	reportLock sync.Mutex
+	levelRules     atomic.Pointer[levelRules]
+	levelRulesLock sync.Mutex
+
+	reportLock sync.RWMutex
 	closeLock  sync.Mutex
 
 	callerStackDepth     int
@@ -44,6 +67,12 @@ type ILogger interface {
 	Report(r ReportType)
 	FlushReports()
 	HandleReports(ctx context.Context)
+	SetDuplicateSuppression(interval time.Duration)
+	SetBackpressurePolicy(policy BackpressurePolicy)
+	SetLevelBackpressurePolicy(level Level, policy BackpressurePolicy)
+	QueueStats() QueueStats
+	Metrics() Metrics
+	FlushDuplicates()
 	Writer() IWriter
 	Memory() memory.IRecordMemory
 	AddStaticFields(attrs map[string]string)
@@ -51,6 +80,22 @@ type ILogger interface {
 	SetReportQueueSize(size uint)
 	SetTraceMode(mode bool)
 	TraceMode() bool
+	SetTimeFormat(format TimeFormat)
+	TimeFormat() TimeFormat
+	SetMinLevel(level Level)
+	MinLevel() Level
+	Enabled(level Level) bool
+	AddLevelRules(rules map[string]Level)
+	DeleteLevelRules(patterns ...string)
+	HasLevelRules() bool
+	SetSampling(sampling Sampling)
+	SetLevelSampling(level Level, sampling Sampling)
+	DeleteSampling()
+	Sample(level Level, ci runtimeinfo.CallerInfo) (written bool, sampled bool, dropped uint64)
+	SetStackTraceLevel(level Level)
+	DisableStackTrace()
+	StackTraceEnabled(level Level) bool
+	EnabledFor(level Level, ci runtimeinfo.CallerInfo) bool
 	SetCallerStackDepth(depth int)
 	GetCallerStackDepth() int
 }
@@ -58,11 +103,16 @@ type ILogger interface {
 func NewLogger() ILogger {
 	logger := &logger{}
 
-	logger.builder = logbuilder.NewLogBuilder()
+	logger.builders = logbuilder.NewPool()
 	logger.logsMemory = memory.NewRecordMemory()
+	logger.logsMemory.SetMaxRecords(defaultMemoryMaxRecords)
 	logger.reports = make(chan ReportType, 100)
+	logger.spill = newSpill()
+	logger.done = make(chan struct{})
 	logger.writer = NewWriter()
 	logger.callerStackDepth = 2 // default depth
+	logger.minLevel.Store(int32(Trace))
+	logger.stackTraceLevel.Store(noStackTrace)
 
 	return logger
 }
@@ -70,6 +120,10 @@ func NewLogger() ILogger {
 func (l *logger) closeReport() {
 	l.closeLock.Lock()
 	defer l.closeLock.Unlock()
+
+	if !l.closed {
+		close(l.done)
+	}
 	l.closed = true
 }
 
@@ -79,47 +133,104 @@ func (l *logger) getStatusCloseReport() bool {
 	return l.closed
 }
 
+// AsyncReport queues the report, the backpressure policy of its level
+// is applied when the queue is full.
 func (l *logger) AsyncReport(r ReportType) {
 	if l.getStatusCloseReport() {
 		return
 	}
+	l.metrics.countEntry(r.Level)
+	defer func() { l.metrics.observeQueue(len(l.reports)) }()
+
+	policy := l.backpressure.policyFor(r.Level)
+	if policy == SpillToDisk && l.spill.pending() {
+		// keeps the order of the reports while the spilled ones are not replayed
+		l.spillReport(r)
+		return
+	}
+
 	select {
 	case l.reports <- r:
-	case <-time.After(1 * time.Second):
-		fmt.Fprintf(os.Stderr, "logger reports channel is full\n")
+	default:
+		l.reportFull(r, policy)
 	}
 }
 
+// Report writes the report to the writers at once.
 func (l *logger) Report(r ReportType) {
-	l.reportLock.Lock()
-	defer l.reportLock.Unlock()
+	l.metrics.countEntry(r.Level)
+	l.write(r)
+}
+
+// write encodes the report with a pooled builder and writes it to the writers.
+func (l *logger) write(r ReportType) {
+	b := l.builders.Get()
+	defer l.builders.Put(b)
+
+	l.reportLock.RLock()
+	entry := l.encode(b, r)
+	l.reportLock.RUnlock()
+
+	_ = l.writer.WriteEntry(r.Level, entry)
+}
+
+// encode encodes the report as a JSON line, the line is valid until the builder is used again.
+// The report lock must be held, at least for reading.
+func (l *logger) encode(b logbuilder.ILogBuilder, r ReportType) []byte {
+	for key, value := range l.staticFields {
+		b.AddString(key, value)
+	}
 
-	if l.staticFields != nil {
-		for key, value := range l.staticFields {
-			l.builder.AddFields(key, value)
+	for _, f := range r.Fields {
+		if e, ok := f.Value.(ErrorValue); ok {
+			addError(b, f.Key, e.Err)
+			continue
 		}
+		b.AddField(f.Key, f.Value)
 	}
 
-	l.builder.AddFields(
-		"time", r.Time,
-		"level", r.Level.String(),
-		"msg", r.Msg,
-		"file", r.CallerInfo.File,
-		"package", r.CallerInfo.Package,
-		"function", r.CallerInfo.Function,
-		"line", strconv.Itoa(r.CallerInfo.Line),
-	)
+	// the time is omitted for a report without time, as the slog records with a zero time
+	if !r.Time.IsZero() {
+		l.timeFormat.add(b, "time", r.Time)
+	}
+	b.AddString("level", r.Level.String())
+	b.AddString("msg", r.Msg)
+	b.AddString("file", r.CallerInfo.File)
+	b.AddString("package", r.CallerInfo.Package)
+	b.AddString("function", r.CallerInfo.Function)
+	b.AddInt("line", int64(r.CallerInfo.Line))
+
+	if len(r.Stack) > 0 {
+		b.AddField("stack", r.Stack)
+	}
+
+	return b.Compile()
+}
+
+// addError adds the fields of an error, a nil error is written as null.
+func addError(b logbuilder.ILogBuilder, key string, err error) {
+	if err == nil {
+		b.AddField(key, nil)
+		return
+	}
 
-	_, _ = l.writer.Write(l.builder.Compile())
+	b.AddField(key, err.Error())
+	b.AddField(key+"_type", errorType(err))
+	b.AddField(key+"_chain", errorChain(err))
 }
 
+// FlushReports writes the queued reports and the summary of the held duplicated reports.
 func (l *logger) FlushReports() {
 	for {
 		select {
 		case r := <-l.reports:
-			l.Report(r)
+			l.handleReport(r)
 
 		case <-time.After(1 * time.Millisecond):
+			l.FlushDuplicates()
+			l.spill.replay(l.writer)
+			// waits for the writers with their own buffer
+			l.writer.Flush()
 			return
 		}
 	}
@@ -133,7 +244,12 @@ func (l *logger) HandleReports(ctx context.Context) {
 			return
 
 		case r := <-l.reports:
-			l.Report(r)
+			l.handleReport(r)
+
+		case <-l.spill.ready:
+			// the queued reports are older than the spilled ones
+			l.drainReports()
+			l.spill.replay(l.writer)
 		}
 	}
 }
@@ -185,6 +301,137 @@ func (l *logger) TraceMode() bool {
 	return l.traceMode
 }
 
+// SetTimeFormat sets how the time of the entries is written,
+// the writers and the formatters which read the time are given the same format.
+func (l *logger) SetTimeFormat(format TimeFormat) {
+	l.reportLock.Lock()
+	l.timeFormat = format
+	l.reportLock.Unlock()
+
+	l.writer.SetTimeFormat(format)
+}
+
+func (l *logger) TimeFormat() TimeFormat {
+	l.reportLock.RLock()
+	defer l.reportLock.RUnlock()
+	return l.timeFormat
+}
+
+// SetMinLevel sets the lowest level written by the logger,
+// the entries below it are discarded before any work is done.
+func (l *logger) SetMinLevel(level Level) {
+	l.minLevel.Store(int32(level))
+}
+
+func (l *logger) MinLevel() Level {
+	return Level(l.minLevel.Load())
+}
+
+// Enabled reports whether the entries of the level may be written,
+// the trace level also depends on the trace mode.
+// When there are level rules, EnabledFor gives the answer for a caller.
+func (l *logger) Enabled(level Level) bool {
+	threshold := l.MinLevel()
+	if rules := l.levelRules.Load(); rules != nil {
+		threshold = min(threshold, rules.lowest)
+	}
+
+	if level < threshold {
+		return false
+	}
+	if level == Trace {
+		return l.TraceMode()
+	}
+	return true
+}
+
+// EnabledFor reports whether the entries of the level are written for the caller,
+// the level of the most specific rule matching the caller replaces the min level.
+func (l *logger) EnabledFor(level Level, ci runtimeinfo.CallerInfo) bool {
+	threshold, ok := l.levelRules.Load().levelFor(ci)
+	if !ok {
+		threshold = l.MinLevel()
+	}
+
+	if level < threshold {
+		return false
+	}
+	if level == Trace {
+		return l.TraceMode()
+	}
+	return true
+}
+
+// AddLevelRules adds or replaces the level rules,
+// the key is the pattern matched against the caller and the value is its min level.
+func (l *logger) AddLevelRules(rules map[string]Level) {
+	l.levelRulesLock.Lock()
+	defer l.levelRulesLock.Unlock()
+	l.levelRules.Store(l.levelRules.Load().with(rules))
+}
+
+// DeleteLevelRules removes the level rules of the patterns.
+func (l *logger) DeleteLevelRules(patterns ...string) {
+	l.levelRulesLock.Lock()
+	defer l.levelRulesLock.Unlock()
+	l.levelRules.Store(l.levelRules.Load().without(patterns...))
+}
+
+func (l *logger) HasLevelRules() bool {
+	return l.levelRules.Load() != nil
+}
+
+// SetSampling sets the sampling of the call sites of all levels,
+// the sampling of a level set by SetLevelSampling takes precedence.
+// The counters of the call sites are reset.
+func (l *logger) SetSampling(sampling Sampling) {
+	l.samplerLock.Lock()
+	defer l.samplerLock.Unlock()
+	l.sampler.Store(l.sampler.Load().withGlobal(sampling))
+}
+
+// SetLevelSampling sets the sampling of the call sites of the level,
+// a Sampling without interval disables the sampling of the level.
+// The counters of the call sites are reset.
+func (l *logger) SetLevelSampling(level Level, sampling Sampling) {
+	l.samplerLock.Lock()
+	defer l.samplerLock.Unlock()
+	l.sampler.Store(l.sampler.Load().withLevel(level, sampling))
+}
+
+// DeleteSampling disables the sampling of all levels.
+func (l *logger) DeleteSampling() {
+	l.samplerLock.Lock()
+	defer l.samplerLock.Unlock()
+	l.sampler.Store(nil)
+}
+
+// Sample counts the entry of the call site of the caller and reports whether it is written.
+// When the level is sampled, dropped is the number of entries of the call site
+// discarded since the last written one.
+func (l *logger) Sample(level Level, ci runtimeinfo.CallerInfo) (written bool, sampled bool, dropped uint64) {
+	s := l.sampler.Load()
+	if s == nil {
+		return true, false, 0
+	}
+	return s.sample(level, ci, time.Now())
+}
+
+// SetStackTraceLevel sets the lowest level whose entries carry the stack trace of the caller.
+func (l *logger) SetStackTraceLevel(level Level) {
+	l.stackTraceLevel.Store(int32(level))
+}
+
+// DisableStackTrace disables the stack traces, which are disabled by default.
+func (l *logger) DisableStackTrace() {
+	l.stackTraceLevel.Store(noStackTrace)
+}
+
+// StackTraceEnabled reports whether the entries of the level carry the stack trace.
+func (l *logger) StackTraceEnabled(level Level) bool {
+	return int32(level) >= l.stackTraceLevel.Load()
+}
+
 func (l *logger) SetCallerStackDepth(depth int) {
 	l.callerStackDepthLock.Lock()
 	defer l.callerStackDepthLock.Unlock()
@@ -3,8 +3,10 @@ package logengine
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -27,10 +29,10 @@ func TestNewLogger(t *testing.T) {
 			t.Fatal("NewLogger did not returned a instance of logger")
 		}
 
-		if _l.builder == nil {
+		if _l.builders == nil {
 			t.Error("expected the momory was instance")
 		}
-		if reflect.ValueOf(_l.builder).IsNil() {
+		if reflect.ValueOf(_l.builders).IsNil() {
 			t.Error("expected the builder was not nil")
 		}
 
@@ -151,7 +153,7 @@ func TestGetStatusCloseReport(t *testing.T) {
 
 func TestAsyncReport(t *testing.T) {
 	r := ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -168,8 +170,8 @@ func TestAsyncReport(t *testing.T) {
 
 		select {
 		case report := <-_l.reports:
-			if report.Time != r.Time {
-				t.Errorf("expected time to be %q, but got %q", r.Time, report.Time)
+			if !report.Time.Equal(r.Time) {
+				t.Errorf("expected time to be %v, but got %v", r.Time, report.Time)
 			}
 			if report.Level != r.Level {
 				t.Errorf("expected level to be %q, but got %q", r.Level, report.Level)
@@ -248,14 +250,14 @@ func (m *mockBufferWriter) String() string {
 
 func TestReport(t *testing.T) {
 	r := ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
 	}
 
-	reportLog := fmt.Sprintf(`"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, r.Time, r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
+	reportLog := fmt.Sprintf(`"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":%d}
+`, r.Time.Format(time.RFC3339), r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
 
 	t.Run("should timout when mutex is lock", func(t *testing.T) {
 		l := NewLogger()
@@ -329,18 +331,93 @@ func TestReport(t *testing.T) {
 			t.Errorf("expected read on buffer %q, but got %q", expectedReport, buf.String())
 		}
 	})
+
+	t.Run("should write the fields of the report after the static fields", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("newlogger did not returned a instance of logger")
+		}
+
+		_l.staticFields = map[string]string{"hello": "world"}
+
+		buf := &bytes.Buffer{}
+		_l.writer.AddWriter(buf)
+
+		rf := r
+		rf.Fields = ToFields("patient_session", "abc", "attempt", 2)
+		l.Report(rf)
+
+		expectedReport := `{"hello":"world","patient_session":"abc","attempt":2,` + reportLog
+		if buf.String() != expectedReport {
+			t.Errorf("expected read on buffer %q, but got %q", expectedReport, buf.String())
+		}
+
+		buf.Reset()
+		l.Report(r)
+
+		expectedReport = `{"hello":"world",` + reportLog
+		if buf.String() != expectedReport {
+			t.Errorf("expected the fields only on one entry, but got %q", buf.String())
+		}
+	})
+
+	t.Run("should write the message, type and chain of the error fields", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("newlogger did not returned a instance of logger")
+		}
+
+		buf := &bytes.Buffer{}
+		_l.writer.AddWriter(buf)
+
+		rf := r
+		rf.Fields = []Field{
+			{Key: "error", Value: ErrorValue{Err: fmt.Errorf("charge: %w", errors.New("declined"))}},
+			{Key: "cause", Value: ErrorValue{}},
+		}
+		l.Report(rf)
+
+		expectedReport := `{"error":"charge: declined","error_type":"*fmt.wrapError",` +
+			`"error_chain":[{"message":"charge: declined","type":"*fmt.wrapError"},{"message":"declined","type":"*errors.errorString"}],` +
+			`"cause":null,` + reportLog
+		if buf.String() != expectedReport {
+			t.Errorf("expected read on buffer %q, but got %q", expectedReport, buf.String())
+		}
+	})
+
+	t.Run("should write the stack after the caller information", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("newlogger did not returned a instance of logger")
+		}
+
+		buf := &bytes.Buffer{}
+		_l.writer.AddWriter(buf)
+
+		rs := r
+		rs.Stack = []runtimeinfo.StackFrame{{Function: "main.main", File: "/src/main.go", Line: 7}}
+		l.Report(rs)
+
+		expectedReport := `{` + strings.TrimSuffix(reportLog, "}\n") + `,"stack":[{"function":"main.main","file":"/src/main.go","line":7}]}` + "\n"
+		if buf.String() != expectedReport {
+			t.Errorf("expected read on buffer %q, but got %q", expectedReport, buf.String())
+		}
+	})
 }
 
 func TestFlushReports(t *testing.T) {
 	r := ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
 	}
 
-	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, r.Time, r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
+	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":%d}
+`, r.Time.Format(time.RFC3339), r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
 
 	t.Run("should not flush any report when buffer reports is empty", func(t *testing.T) {
 		l := NewLogger()
@@ -381,14 +458,14 @@ func TestFlushReports(t *testing.T) {
 
 func TestHandleReports(t *testing.T) {
 	r := ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
 	}
 
-	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, r.Time, r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
+	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":%d}
+`, r.Time.Format(time.RFC3339), r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
 
 	t.Run("should handle the report and close the logger", func(t *testing.T) {
 		l := NewLogger()
@@ -863,3 +940,79 @@ func TestCallerStackDepth(t *testing.T) {
 		}
 	})
 }
+
+func TestMinLevel(t *testing.T) {
+	t.Run("should have trace as the default min level", func(t *testing.T) {
+		l := NewLogger()
+
+		if l.MinLevel() != Trace {
+			t.Errorf("expected the default min level to be %v, but got %v", Trace, l.MinLevel())
+		}
+	})
+
+	t.Run("should set and get the min level", func(t *testing.T) {
+		l := NewLogger()
+
+		l.SetMinLevel(Warn)
+		if l.MinLevel() != Warn {
+			t.Errorf("expected the min level to be %v, but got %v", Warn, l.MinLevel())
+		}
+	})
+}
+
+func TestStackTraceEnabled(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		l := NewLogger()
+
+		if l.StackTraceEnabled(Fatal) {
+			t.Error("expected the stack trace to be disabled")
+		}
+	})
+
+	t.Run("should be enabled from the stack trace level", func(t *testing.T) {
+		l := NewLogger()
+		l.SetStackTraceLevel(Error)
+
+		if l.StackTraceEnabled(Warn) {
+			t.Error("expected the stack trace to be disabled for warn")
+		}
+		if !l.StackTraceEnabled(Error) || !l.StackTraceEnabled(Fatal) {
+			t.Error("expected the stack trace to be enabled for error and above")
+		}
+
+		l.DisableStackTrace()
+		if l.StackTraceEnabled(Fatal) {
+			t.Error("expected the stack trace to be disabled")
+		}
+	})
+}
+
+func TestEnabled(t *testing.T) {
+	testCases := [...]struct {
+		name      string
+		minLevel  Level
+		traceMode bool
+		level     Level
+		expected  bool
+	}{
+		{name: "debug is enabled by default", minLevel: Trace, level: Debug, expected: true},
+		{name: "trace is disabled without trace mode", minLevel: Trace, level: Trace, expected: false},
+		{name: "trace is enabled with trace mode", minLevel: Trace, traceMode: true, level: Trace, expected: true},
+		{name: "trace is disabled by the min level", minLevel: Debug, traceMode: true, level: Trace, expected: false},
+		{name: "debug is disabled by the min level", minLevel: Warn, level: Debug, expected: false},
+		{name: "warn is enabled by the min level", minLevel: Warn, level: Warn, expected: true},
+		{name: "error is enabled by the min level", minLevel: Warn, level: Error, expected: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLogger()
+			l.SetMinLevel(tt.minLevel)
+			l.SetTraceMode(tt.traceMode)
+
+			if enabled := l.Enabled(tt.level); enabled != tt.expected {
+				t.Errorf("expected enabled to be %v, but got %v", tt.expected, enabled)
+			}
+		})
+	}
+}
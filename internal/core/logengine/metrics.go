@@ -0,0 +1,54 @@
+package logengine
+
+import "sync/atomic"
+
+// levelCount is the number of levels, from Trace to Fatal.
+const levelCount = int(Fatal-Trace) + 1
+
+// Metrics are the metrics of the logger pipeline,
+// Entries counts the reports by level, including the dropped ones.
+type Metrics struct {
+	Entries map[Level]uint64
+	Queue   QueueStats
+	Writers []WriterStats
+}
+
+// metrics counts the entries by level and keeps the high-water mark of the reports queue.
+type metrics struct {
+	entries       [levelCount]atomic.Uint64
+	highWaterMark atomic.Int64
+}
+
+func (m *metrics) countEntry(level Level) {
+	if level < Trace || level > Fatal {
+		return
+	}
+	m.entries[level-Trace].Add(1)
+}
+
+// observeQueue raises the high-water mark to the depth of the queue.
+func (m *metrics) observeQueue(depth int) {
+	for {
+		mark := m.highWaterMark.Load()
+		if int64(depth) <= mark || m.highWaterMark.CompareAndSwap(mark, int64(depth)) {
+			return
+		}
+	}
+}
+
+func (m *metrics) entriesByLevel() map[Level]uint64 {
+	entries := make(map[Level]uint64, levelCount)
+	for i := range m.entries {
+		entries[Trace+Level(i)] = m.entries[i].Load()
+	}
+	return entries
+}
+
+// Metrics returns the entries reported by level, the metrics of the reports queue and of the writers.
+func (l *logger) Metrics() Metrics {
+	return Metrics{
+		Entries: l.metrics.entriesByLevel(),
+		Queue:   l.QueueStats(),
+		Writers: l.writer.Stats(),
+	}
+}
@@ -0,0 +1,131 @@
+package logengine
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+// Sampling limits the entries written by a call site: the first entries of every interval
+// are written, and after them only every Thereafter-th entry is written.
+// A Sampling without interval does not sample the entries.
+type Sampling struct {
+	First      uint64
+	Thereafter uint64
+	Interval   time.Duration
+}
+
+func (s Sampling) enabled() bool {
+	return s.Interval > 0
+}
+
+// callSite identifies the call site of an entry.
+type callSite struct {
+	pkg   string
+	file  string
+	line  int
+	level Level
+}
+
+// siteCounter counts the entries of a call site in the current interval.
+type siteCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// sampler is an immutable sampling configuration with the counters of the call sites,
+// a new sampler, with new counters, is created on every change of the configuration.
+type sampler struct {
+	global   Sampling
+	levels   map[Level]Sampling
+	counters sync.Map
+}
+
+func newSampler(global Sampling, levels map[Level]Sampling) *sampler {
+	if !global.enabled() && len(levels) == 0 {
+		return nil
+	}
+	return &sampler{global: global, levels: levels}
+}
+
+// withGlobal returns a copy of the sampler with the sampling of all levels.
+func (s *sampler) withGlobal(global Sampling) *sampler {
+	if s == nil {
+		return newSampler(global, nil)
+	}
+	return newSampler(global, s.levels)
+}
+
+// withLevel returns a copy of the sampler with the sampling of the level,
+// it replaces the sampling of all levels for the level.
+func (s *sampler) withLevel(level Level, sampling Sampling) *sampler {
+	if s == nil {
+		return newSampler(Sampling{}, map[Level]Sampling{level: sampling})
+	}
+
+	levels := maps.Clone(s.levels)
+	if levels == nil {
+		levels = map[Level]Sampling{}
+	}
+	levels[level] = sampling
+
+	return newSampler(s.global, levels)
+}
+
+func (s *sampler) samplingFor(level Level) Sampling {
+	if sampling, ok := s.levels[level]; ok {
+		return sampling
+	}
+	return s.global
+}
+
+// sample counts the entry of the call site and reports whether it is written,
+// sampled is false when the level is not sampled.
+// dropped is the number of entries of the call site discarded since the last written one.
+func (s *sampler) sample(level Level, ci runtimeinfo.CallerInfo, now time.Time) (written bool, sampled bool, dropped uint64) {
+	if s == nil {
+		return true, false, 0
+	}
+
+	sampling := s.samplingFor(level)
+	if !sampling.enabled() {
+		return true, false, 0
+	}
+
+	site := callSite{pkg: ci.Package, file: ci.File, line: ci.Line, level: level}
+	c, ok := s.counters.Load(site)
+	if !ok {
+		c, _ = s.counters.LoadOrStore(site, &siteCounter{})
+	}
+	counter := c.(*siteCounter)
+
+	n := counter.inc(now, sampling.Interval)
+	if n > sampling.First && (sampling.Thereafter == 0 || (n-sampling.First)%sampling.Thereafter != 0) {
+		counter.dropped.Add(1)
+		return false, true, 0
+	}
+
+	return true, true, counter.dropped.Swap(0)
+}
+
+// inc counts an entry and returns its position in the current interval,
+// a new interval begins when the current one is over.
+func (c *siteCounter) inc(now time.Time, interval time.Duration) uint64 {
+	tn := now.UnixNano()
+
+	resetAt := c.resetAt.Load()
+	if resetAt > tn {
+		return c.count.Add(1)
+	}
+
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, tn+interval.Nanoseconds()) {
+		// another entry began the interval
+		return c.count.Add(1)
+	}
+	return 1
+}
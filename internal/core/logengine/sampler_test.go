@@ -0,0 +1,164 @@
+package logengine
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+func TestSample(t *testing.T) {
+	ci := runtimeinfo.CallerInfo{File: "main.go", Package: "main", Function: "main", Line: 10}
+	now := time.Unix(1700000000, 0)
+
+	t.Run("should write every entry when there is no sampling", func(t *testing.T) {
+		var s *sampler
+
+		for range 10 {
+			if written, sampled, _ := s.sample(Warn, ci, now); !written || sampled {
+				t.Fatal("expected the entry to be written without sampling")
+			}
+		}
+	})
+
+	t.Run("should write the first entries and then every thereafter entry", func(t *testing.T) {
+		s := newSampler(Sampling{First: 2, Thereafter: 3, Interval: time.Second}, nil)
+
+		var written []bool
+		var dropped []uint64
+		for range 8 {
+			w, sampled, d := s.sample(Warn, ci, now)
+			if !sampled {
+				t.Fatal("expected the level to be sampled")
+			}
+			written = append(written, w)
+			if w {
+				dropped = append(dropped, d)
+			}
+		}
+
+		expectedWritten := []bool{true, true, false, false, true, false, false, true}
+		for i := range written {
+			if written[i] != expectedWritten[i] {
+				t.Fatalf("expected the written entries to be %v, but got %v", expectedWritten, written)
+			}
+		}
+
+		expectedDropped := []uint64{0, 0, 2, 2}
+		for i := range dropped {
+			if dropped[i] != expectedDropped[i] {
+				t.Fatalf("expected the dropped counts to be %v, but got %v", expectedDropped, dropped)
+			}
+		}
+	})
+
+	t.Run("should drop every entry after the first ones when thereafter is zero", func(t *testing.T) {
+		s := newSampler(Sampling{First: 1, Interval: time.Second}, nil)
+
+		if written, _, _ := s.sample(Warn, ci, now); !written {
+			t.Error("expected the first entry to be written")
+		}
+		for range 5 {
+			if written, _, _ := s.sample(Warn, ci, now); written {
+				t.Error("expected the entry to be dropped")
+			}
+		}
+	})
+
+	t.Run("should begin a new interval", func(t *testing.T) {
+		s := newSampler(Sampling{First: 1, Interval: time.Second}, nil)
+
+		s.sample(Warn, ci, now)
+		s.sample(Warn, ci, now)
+		s.sample(Warn, ci, now.Add(500*time.Millisecond))
+
+		written, _, dropped := s.sample(Warn, ci, now.Add(time.Second))
+		if !written || dropped != 2 {
+			t.Errorf("expected the first entry of the interval to be written with 2 dropped, but got %v and %d", written, dropped)
+		}
+	})
+
+	t.Run("should count the call sites apart", func(t *testing.T) {
+		s := newSampler(Sampling{First: 1, Interval: time.Second}, nil)
+
+		other := ci
+		other.Line = 20
+
+		s.sample(Warn, ci, now)
+		if written, _, _ := s.sample(Warn, other, now); !written {
+			t.Error("expected the first entry of the other call site to be written")
+		}
+	})
+
+	t.Run("should use the sampling of the level", func(t *testing.T) {
+		s := newSampler(Sampling{First: 1, Interval: time.Second}, nil).
+			withLevel(Error, Sampling{}).
+			withLevel(Debug, Sampling{First: 2, Interval: time.Second})
+
+		for range 3 {
+			if written, sampled, _ := s.sample(Error, ci, now); !written || sampled {
+				t.Fatal("expected the error entries not to be sampled")
+			}
+		}
+
+		for i := range 3 {
+			written, _, _ := s.sample(Debug, ci, now)
+			if written != (i < 2) {
+				t.Fatalf("expected only the first two debug entries to be written, but the %d° was %v", i+1, written)
+			}
+		}
+
+		s.sample(Warn, ci, now)
+		if written, _, _ := s.sample(Warn, ci, now); written {
+			t.Error("expected the global sampling for the warn entries")
+		}
+	})
+
+	t.Run("should count the entries of concurrent callers", func(t *testing.T) {
+		s := newSampler(Sampling{First: 10, Interval: time.Hour}, nil)
+
+		// the first entry begins the interval
+		s.sample(Warn, ci, now)
+
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+		count := 1
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 10 {
+					if written, _, _ := s.sample(Warn, ci, now); written {
+						lock.Lock()
+						count++
+						lock.Unlock()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if count != 10 {
+			t.Errorf("expected 10 entries to be written, but got %d", count)
+		}
+	})
+}
+
+func TestSetSampling(t *testing.T) {
+	t.Run("should sample and reset the sampling", func(t *testing.T) {
+		l := NewLogger()
+		ci := runtimeinfo.CallerInfo{File: "main.go", Line: 1}
+
+		l.SetSampling(Sampling{First: 1, Interval: time.Hour})
+		l.Sample(Info, ci)
+		if written, _, _ := l.Sample(Info, ci); written {
+			t.Error("expected the second entry to be dropped")
+		}
+
+		l.DeleteSampling()
+		if written, sampled, _ := l.Sample(Info, ci); !written || sampled {
+			t.Error("expected the entry to be written without sampling")
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package logengine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// spillChunkSize is the size of the chunks read from the spill file.
+const spillChunkSize = 64 * 1024
+
+// spill keeps the encoded reports in a temporary file while the queue is full,
+// every line is the level of the report, a space and the encoded report.
+// The file is created on the first spilled report and removed once it is replayed.
+type spill struct {
+	file     *os.File
+	writeOff int64
+	readOff  int64
+	count    uint64
+
+	// ready is signaled when a report is spilled
+	ready chan struct{}
+
+	lock sync.Mutex
+}
+
+func newSpill() *spill {
+	return &spill{ready: make(chan struct{}, 1)}
+}
+
+// write appends the level and the encoded report to the spill file.
+func (s *spill) write(level Level, entry []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		file, err := os.CreateTemp("", "ionlog-spill-*.log")
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+
+	line := make([]byte, 0, len(entry)+3)
+	line = strconv.AppendInt(line, int64(level), 10)
+	line = append(line, ' ')
+	line = append(line, entry...)
+
+	n, err := s.file.WriteAt(line, s.writeOff)
+	s.writeOff += int64(n)
+	if err != nil {
+		return err
+	}
+	s.count++
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// pending reports whether there are spilled reports not replayed yet.
+func (s *spill) pending() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readOff < s.writeOff
+}
+
+func (s *spill) spilled() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.count
+}
+
+// replay writes the spilled reports to the writer, one report per write,
+// and removes the spill file when every report was written.
+func (s *spill) replay(w IWriter) {
+	buf := make([]byte, spillChunkSize)
+
+	for {
+		lines, ok := s.readChunk(buf)
+		if !ok {
+			return
+		}
+
+		for len(lines) > 0 {
+			i := bytes.IndexByte(lines, '\n')
+			writeSpilled(w, lines[:i+1])
+			lines = lines[i+1:]
+		}
+	}
+}
+
+// writeSpilled writes a spilled line to the writer with the level of the report.
+func writeSpilled(w IWriter, line []byte) {
+	prefix, entry, ok := bytes.Cut(line, []byte{' '})
+	level, err := strconv.Atoi(string(prefix))
+	if !ok || err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the level of the spilled report: %q\n", line)
+		return
+	}
+	_ = w.WriteEntry(Level(level), entry)
+}
+
+// readChunk reads the next complete lines of the spill file,
+// it returns false when every line was read.
+func (s *spill) readChunk(buf []byte) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.readOff >= s.writeOff {
+		s.remove()
+		return nil, false
+	}
+
+	size := min(int64(len(buf)), s.writeOff-s.readOff)
+	n, err := s.file.ReadAt(buf[:size], s.readOff)
+	if err != nil && err != io.EOF {
+		fmt.Fprintf(os.Stderr, "Failed to read the spilled reports: %v\n", err)
+		s.remove()
+		return nil, false
+	}
+
+	end := bytes.LastIndexByte(buf[:n], '\n')
+	if end < 0 {
+		// a report larger than the chunk is read in a larger buffer
+		large := make([]byte, s.writeOff-s.readOff)
+		n, _ = s.file.ReadAt(large, s.readOff)
+		buf = large
+		end = bytes.LastIndexByte(buf[:n], '\n')
+		if end < 0 {
+			s.remove()
+			return nil, false
+		}
+	}
+
+	s.readOff += int64(end + 1)
+	return buf[:end+1], true
+}
+
+// remove closes and removes the spill file. The lock must be held.
+func (s *spill) remove() {
+	if s.file == nil {
+		return
+	}
+
+	name := s.file.Name()
+	if err := s.file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to close the spill file: %v\n", err)
+	}
+	if err := os.Remove(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove the spill file: %v\n", err)
+	}
+
+	s.file = nil
+	s.writeOff = 0
+	s.readOff = 0
+}
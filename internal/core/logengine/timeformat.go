@@ -0,0 +1,143 @@
+package logengine
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logbuilder"
+)
+
+// The layouts of the Unix times, written as numbers.
+const (
+	UnixSeconds = "unix"
+	UnixMillis  = "unixms"
+	UnixNanos   = "unixns"
+)
+
+// TimeFormat is how the time of the entries is written: Layout is a layout of time.Format,
+// as time.RFC3339Nano, or one of the Unix layouts, and UTC writes the time in UTC
+// in place of the local time. A TimeFormat without layout writes time.RFC3339.
+type TimeFormat struct {
+	Layout string
+	UTC    bool
+}
+
+func (f TimeFormat) layout() string {
+	if f.Layout == "" {
+		return time.RFC3339
+	}
+	return f.Layout
+}
+
+func (f TimeFormat) unix() bool {
+	switch f.Layout {
+	case UnixSeconds, UnixMillis, UnixNanos:
+		return true
+	}
+	return false
+}
+
+func (f TimeFormat) zone(t time.Time) time.Time {
+	if f.UTC {
+		return t.UTC()
+	}
+	return t
+}
+
+// unixTime returns the Unix time with the precision of the layout.
+func (f TimeFormat) unixTime(t time.Time) int64 {
+	switch f.Layout {
+	case UnixMillis:
+		return t.UnixMilli()
+	case UnixNanos:
+		return t.UnixNano()
+	default:
+		return t.Unix()
+	}
+}
+
+// add adds the time field to the builder, as a number for the Unix layouts.
+func (f TimeFormat) add(b logbuilder.ILogBuilder, key string, t time.Time) {
+	if f.unix() {
+		b.AddInt(key, f.unixTime(t))
+		return
+	}
+	b.AddTime(key, f.zone(t), f.layout())
+}
+
+// value returns the time as the value of a field.
+func (f TimeFormat) value(t time.Time) any {
+	if f.unix() {
+		return f.unixTime(t)
+	}
+	return f.zone(t).Format(f.layout())
+}
+
+// Parse parses the time written with the format, the Unix times are returned
+// in UTC or in the local time as the format.
+func (f TimeFormat) Parse(s string) (time.Time, error) {
+	if !f.unix() {
+		return time.Parse(f.layout(), s)
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var t time.Time
+	switch f.Layout {
+	case UnixMillis:
+		t = time.UnixMilli(n)
+	case UnixNanos:
+		t = time.Unix(0, n)
+	default:
+		t = time.Unix(n, 0)
+	}
+	return f.zone(t), nil
+}
+
+// timeFormatWriter is implemented by the writers which read the time of the entries,
+// as the colored lines of the terminal, they are written with the time format of the logger.
+type timeFormatWriter interface {
+	WriteTimeFormat(p []byte, format TimeFormat) (int, error)
+}
+
+// timeFormatFormatter is implemented by the formatters which read the time of the entries,
+// they are replaced by their formatter for the time format of the logger.
+type timeFormatFormatter interface {
+	ForTimeFormat(format TimeFormat) Formatter
+}
+
+// writeTimeFormat writes p to the writer, with the time format for the writers which read the time.
+func writeTimeFormat(w io.Writer, p []byte, format TimeFormat) (int, error) {
+	if t, ok := w.(timeFormatWriter); ok {
+		return t.WriteTimeFormat(p, format)
+	}
+	return w.Write(p)
+}
+
+// SetTimeFormat sets the time format of the entries for the writers and the formatters
+// which read the time, the formatters set later are given the same format.
+func (i *ionWriter) SetTimeFormat(format TimeFormat) {
+	i.writeLock.Lock()
+	defer i.writeLock.Unlock()
+
+	i.configLock.Lock()
+	i.timeFormat = format
+	i.configLock.Unlock()
+
+	for _, state := range i.states {
+		state.options.Formatter = i.formatterFor(state.options.Formatter)
+	}
+}
+
+// formatterFor returns the formatter for the time format, the formatters
+// which do not read the time are returned as they are. The writeLock must be held.
+func (i *ionWriter) formatterFor(formatter Formatter) Formatter {
+	if t, ok := formatter.(timeFormatFormatter); ok {
+		return t.ForTimeFormat(i.config().timeFormat)
+	}
+	return formatter
+}
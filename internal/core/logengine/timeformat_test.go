@@ -0,0 +1,134 @@
+package logengine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logbuilder"
+)
+
+func TestTimeFormat(t *testing.T) {
+	zone := time.FixedZone("BRT", -3*60*60)
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 123456789, zone)
+
+	tests := []struct {
+		name     string
+		format   TimeFormat
+		expected string
+	}{
+		{name: "should write RFC3339 by default", format: TimeFormat{}, expected: `"2025-01-02T03:04:05-03:00"`},
+		{name: "should write the layout", format: TimeFormat{Layout: time.RFC3339Nano}, expected: `"2025-01-02T03:04:05.123456789-03:00"`},
+		{name: "should write the time in UTC", format: TimeFormat{Layout: time.RFC3339Nano, UTC: true}, expected: `"2025-01-02T06:04:05.123456789Z"`},
+		{name: "should write a custom layout", format: TimeFormat{Layout: "2006/01/02 15:04:05.000"}, expected: `"2025/01/02 03:04:05.123"`},
+		{name: "should write the Unix seconds as a number", format: TimeFormat{Layout: UnixSeconds}, expected: "1735797845"},
+		{name: "should write the Unix milliseconds as a number", format: TimeFormat{Layout: UnixMillis}, expected: "1735797845123"},
+		{name: "should write the Unix nanoseconds as a number", format: TimeFormat{Layout: UnixNanos}, expected: "1735797845123456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := logbuilder.NewLogBuilder()
+			tt.format.add(b, "time", ts)
+
+			expected := `{"time":` + tt.expected + "}\n"
+			if entry := string(b.Compile()); entry != expected {
+				t.Errorf("expected the entry to be %q, but got %q", expected, entry)
+			}
+
+			parsed, err := tt.format.Parse(strings.Trim(tt.expected, `"`))
+			if err != nil {
+				t.Fatalf("expected no error to parse the time, but got %v", err)
+			}
+			if tt.format.Layout != "2006/01/02 15:04:05.000" && parsed.Unix() != ts.Unix() {
+				t.Errorf("expected the parsed time to be %v, but got %v", ts, parsed)
+			}
+		})
+	}
+
+	t.Run("should write the times of the duplicates summary with the format", func(t *testing.T) {
+		l := NewLogger()
+		_l, ok := l.(*logger)
+		if !ok {
+			t.Fatalf("NewLogger did not returned a instance of logger")
+		}
+
+		buf := &mockBufferWriter{}
+		_l.writer.AddWriter(buf)
+		_l.SetTimeFormat(TimeFormat{Layout: UnixSeconds})
+		_l.SetDuplicateSuppression(time.Minute)
+
+		for range 3 {
+			_l.handleReport(ReportType{Time: ts, Level: Warn, Msg: "disk full"})
+		}
+		_l.FlushDuplicates()
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+		if entries[1]["first_time"] != float64(ts.Unix()) || entries[1]["time"] != float64(ts.Unix()) {
+			t.Errorf("expected the times of the summary as Unix seconds, but got %v", entries[1])
+		}
+	})
+}
+
+// formatRecorder is a writer which records the time format of its writes.
+type formatRecorder struct {
+	mockBufferWriter
+	formats []TimeFormat
+}
+
+func (f *formatRecorder) WriteTimeFormat(p []byte, format TimeFormat) (int, error) {
+	f.formats = append(f.formats, format)
+	return f.Write(p)
+}
+
+// layoutFormatter is a formatter which reads the time with its format.
+type layoutFormatter struct {
+	format TimeFormat
+}
+
+func (l *layoutFormatter) Format(entry []byte) ([]byte, error) {
+	return append([]byte(l.format.Layout+" "), entry...), nil
+}
+
+func (l *layoutFormatter) ForTimeFormat(format TimeFormat) Formatter {
+	return &layoutFormatter{format: format}
+}
+
+func TestWriterTimeFormat(t *testing.T) {
+	t.Run("should write with the time format the writers which read the time", func(t *testing.T) {
+		w := NewWriter()
+		recorder := &formatRecorder{}
+		w.AddWriter(recorder)
+
+		w.SetTimeFormat(TimeFormat{Layout: UnixMillis})
+		if err := w.WriteEntry(Info, []byte("entry\n")); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if len(recorder.formats) != 1 || recorder.formats[0].Layout != UnixMillis {
+			t.Errorf("expected the entry written with the time format, but got %v", recorder.formats)
+		}
+	})
+
+	t.Run("should give the formatters the time format, before and after they are set", func(t *testing.T) {
+		w := NewWriter()
+		before := &mockBufferWriter{}
+		after := &mockBufferWriter{}
+
+		w.SetWriterOptions(before, WriterOptions{Formatter: &layoutFormatter{}})
+		w.SetTimeFormat(TimeFormat{Layout: UnixNanos})
+		w.SetWriterOptions(after, WriterOptions{Formatter: &layoutFormatter{}})
+		w.AddWriter(before, after)
+
+		if err := w.WriteEntry(Info, []byte("entry\n")); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if before.String() != "unixns entry\n" || after.String() != "unixns entry\n" {
+			t.Errorf("expected the entries formatted with the time format, but got %q and %q", before, after)
+		}
+	})
+}
@@ -1,48 +1,324 @@
 package logengine
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"slices"
 	"sync"
+	"time"
 )
 
 type ionWriter struct {
 	writeLock sync.Mutex
 	writers   []io.Writer
+	states    map[io.Writer]*writerState
+	formatted []formattedEntry
+
+	// the config is guarded by its own lock, so the delivery goroutines
+	// read it without waiting for the writeLock
+	configLock   sync.Mutex
+	retry        RetryPolicy
+	breaker      CircuitBreaker
+	errorHandler func(w io.Writer, err error)
+	timeFormat   TimeFormat
+
+	now   func() time.Time
+	sleep func(d time.Duration)
 }
 
 type IWriter interface {
 	io.Writer
+	Sync()
+	Flush()
+	Close()
 	AddWriter(writer ...io.Writer)
 	DeleteWriter(writer ...io.Writer)
+	WriteEntry(level Level, entry []byte) error
+	SetWriterOptions(w io.Writer, options WriterOptions)
+	Stats() []WriterStats
+	SetRetryPolicy(retry RetryPolicy)
+	SetCircuitBreaker(breaker CircuitBreaker)
+	SetErrorHandler(handler func(w io.Writer, err error))
+	SetTimeFormat(format TimeFormat)
+}
+
+// WriterStats are the metrics of a writer, Name is the name of the file
+// for the writers with a Name method, as *os.File, or the type of the writer.
+// Dropped are the entries dropped because the buffer of the writer was full
+// or because the writer was disabled by the circuit breaker.
+type WriterStats struct {
+	Name    string
+	State   WriterState
+	Bytes   uint64
+	Errors  uint64
+	Dropped uint64
+}
+
+// syncer is implemented by the writers which buffer the data, as *os.File.
+type syncer interface {
+	Sync() error
+}
+
+// writeFailure is a failed write, reported to the error handler.
+type writeFailure struct {
+	index    int
+	writer   io.Writer
+	err      error
+	disabled bool
+}
+
+// delivery is an entry to write to a writer from the caller,
+// or to queue for the writer delivered from its own goroutine.
+type delivery struct {
+	index  int
+	writer io.Writer
+	state  *writerState
+	async  *asyncDelivery
+	entry  []byte
+}
+
+// writerConfig is a snapshot of the retry, circuit breaker, error handler and time format of the writers.
+type writerConfig struct {
+	retry        RetryPolicy
+	breaker      CircuitBreaker
+	errorHandler func(w io.Writer, err error)
+	timeFormat   TimeFormat
 }
 
 func NewWriter() IWriter {
-	return &ionWriter{}
+	return &ionWriter{now: time.Now, sleep: time.Sleep}
 }
 
-// Write writes the contents of p to all writeTargets
-// This function returns no error nor the number of bytes written
+func (i *ionWriter) config() writerConfig {
+	i.configLock.Lock()
+	defer i.configLock.Unlock()
+	return writerConfig{retry: i.retry, breaker: i.breaker, errorHandler: i.errorHandler, timeFormat: i.timeFormat}
+}
+
+// Write writes the contents of p to all writeTargets, whatever their levels,
+// formatted by the formatter of every writer.
+// This function returns the length of p, or the errors of the failed writers
 func (i *ionWriter) Write(p []byte) (int, error) {
+	if err := i.write(p, Info, false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry writes the JSON entry to the writeTargets which accept its level,
+// formatted by the formatter of every writer.
+// This function returns the errors of the failed writers
+func (i *ionWriter) WriteEntry(level Level, entry []byte) error {
+	return i.write(entry, level, true)
+}
+
+// write writes the entry to all writeTargets, the transient errors are retried
+// by the retry policy and the writers disabled by the circuit breaker are skipped.
+// The entry is formatted once per distinct formatter, and it is queued
+// for the writers delivered from their own goroutine. The writers are written and queued
+// after the writeLock is released, so a slow or full writer does not hold the other writers.
+func (i *ionWriter) write(p []byte, level Level, filter bool) error {
+	cfg := i.config()
+
+	var buf [8]delivery
+	deliveries, failures := i.plan(buf[:0], p, level, filter, cfg)
+
+	// the writers without buffer go first, so they are not held by a full buffer
+	for _, d := range deliveries {
+		if d.async == nil {
+			failures = i.deliverNow(d, cfg, failures)
+		}
+	}
+
+	for _, d := range deliveries {
+		if d.async == nil {
+			continue
+		}
+
+		dropped, queued := d.async.enqueue(d.entry)
+		d.state.drop(dropped)
+		if !queued {
+			failures = i.deliverNow(d, cfg, failures)
+		}
+	}
+
+	var errs []error
+	for _, f := range failures {
+		if f.disabled {
+			fmt.Fprintf(os.Stderr, "Disabled the %v° target after %v failed writes\n", f.index+1, cfg.breaker.Failures)
+		}
+
+		errs = append(errs, fmt.Errorf("%v° target: %w", f.index+1, f.err))
+
+		// the handler is called without the lock, so it can log
+		if cfg.errorHandler != nil {
+			cfg.errorHandler(f.writer, f.err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Failed to write to in the %v° target, error: %v\n", f.index+1, f.err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// plan formats the entry for the writers which accept its level,
+// it returns the entries to write or to queue and the failed formats.
+func (i *ionWriter) plan(deliveries []delivery, p []byte, level Level, filter bool, cfg writerConfig) ([]delivery, []writeFailure) {
 	i.writeLock.Lock()
 	defer i.writeLock.Unlock()
+	defer i.clearFormatted()
 
+	var failures []writeFailure
 	for index, w := range i.writers {
 		if w == nil {
 			fmt.Fprintf(os.Stderr, "Expected the %v° target to be not nil\n", index+1)
 			continue
 		}
 
-		_, err := w.Write(p)
+		state := i.writerState(w)
+		if filter && !state.options.accept(level) {
+			continue
+		}
+
+		entry, err := i.format(state.options.Formatter, p)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to write to in the %v° target, error: %v\n", index+1, err)
+			disabled := state.record(0, err, i.now(), cfg.breaker)
+			failures = append(failures, writeFailure{index: index, writer: w, err: err, disabled: disabled})
 			continue
 		}
+
+		deliveries = append(deliveries, delivery{index: index, writer: w, state: state, async: state.async, entry: entry})
 	}
 
-	return 0, nil
+	return deliveries, failures
+}
+
+// deliverNow writes the entry from the caller, the failure is appended to the failures.
+func (i *ionWriter) deliverNow(d delivery, cfg writerConfig, failures []writeFailure) []writeFailure {
+	disabled, err := i.deliverLocked(d.writer, d.state, d.entry, cfg)
+	if err != nil {
+		failures = append(failures, writeFailure{index: d.index, writer: d.writer, err: err, disabled: disabled})
+	}
+	return failures
+}
+
+// deliverLocked delivers the entry holding the writing lock of the writer,
+// the lock is released even when the writer panics.
+func (i *ionWriter) deliverLocked(w io.Writer, state *writerState, entry []byte, cfg writerConfig) (bool, error) {
+	state.writing.Lock()
+	defer state.writing.Unlock()
+	return i.deliver(w, state, entry, cfg)
+}
+
+// deliver writes the entry to the writer, unless it is disabled by the circuit breaker,
+// and records the result on the state of the writer, the entries of a disabled writer are dropped.
+// It returns the error of the write and whether the error disabled the writer.
+// The writing lock of the writer must be held.
+func (i *ionWriter) deliver(w io.Writer, state *writerState, entry []byte, cfg writerConfig) (bool, error) {
+	if !state.allow(i.now(), cfg.breaker) {
+		state.drop(1)
+		return false, nil
+	}
+
+	n, err := i.writeRetry(w, entry, cfg)
+	return state.record(n, err, i.now(), cfg.breaker), err
+}
+
+// Sync commits the written data of all writeTargets which implement the Sync method,
+// as the files, to the storage. The buffered entries are written before.
+// The writers are synced after the writeLock is released, so the other writers are not held.
+// This function returns no error
+func (i *ionWriter) Sync() {
+	i.writeLock.Lock()
+	var syncs []delivery
+	for index, w := range i.writers {
+		if _, ok := w.(syncer); ok {
+			state := i.writerState(w)
+			syncs = append(syncs, delivery{index: index, writer: w, state: state, async: state.async})
+		}
+	}
+	i.writeLock.Unlock()
+
+	for _, d := range syncs {
+		if err := syncWriter(d); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to sync the %v° target, error: %v\n", d.index+1, err)
+		}
+	}
+}
+
+// syncWriter syncs the writer, after its buffered entries are written.
+func syncWriter(d delivery) error {
+	if d.async != nil {
+		d.async.flush()
+	}
+
+	d.state.writing.Lock()
+	defer d.state.writing.Unlock()
+	return d.writer.(syncer).Sync()
+}
+
+// Flush waits until the buffered entries of every writer are written,
+// without holding the writeLock, so the entries keep being written while it waits.
+func (i *ionWriter) Flush() {
+	i.writeLock.Lock()
+	var asyncs []*asyncDelivery
+	for _, state := range i.states {
+		if state.async != nil {
+			asyncs = append(asyncs, state.async)
+		}
+	}
+	i.writeLock.Unlock()
+
+	for _, d := range asyncs {
+		d.flush()
+	}
+}
+
+// Close writes the buffered entries and stops the goroutines of the writers,
+// the next entries are written from the caller.
+func (i *ionWriter) Close() {
+	i.writeLock.Lock()
+	defer i.writeLock.Unlock()
+
+	for _, state := range i.states {
+		state.stopAsync()
+	}
+}
+
+// Stats returns the metrics of the writers, in the order they were added.
+func (i *ionWriter) Stats() []WriterStats {
+	i.writeLock.Lock()
+	defer i.writeLock.Unlock()
+
+	stats := make([]WriterStats, 0, len(i.writers))
+	for _, w := range i.writers {
+		s := i.writerState(w)
+		stats = append(stats, s.stats(writerName(w)))
+	}
+	return stats
+}
+
+// writerState returns the state of the writer, the writeLock must be held.
+func (i *ionWriter) writerState(w io.Writer) *writerState {
+	if i.states == nil {
+		i.states = map[io.Writer]*writerState{}
+	}
+
+	s, ok := i.states[w]
+	if !ok {
+		s = &writerState{}
+		i.states[w] = s
+	}
+	return s
+}
+
+func writerName(w io.Writer) string {
+	if n, ok := w.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", w)
 }
 
 func (i *ionWriter) AddWriter(writer ...io.Writer) {
@@ -67,6 +343,10 @@ func (i *ionWriter) DeleteWriter(writer ...io.Writer) {
 			if wd == w {
 				isFind = true
 				i.writers = slices.Delete(i.writers, index, index+1)
+				if state, ok := i.states[w]; ok {
+					state.stopAsync()
+					delete(i.states, w)
+				}
 				break
 			}
 		}
@@ -32,6 +32,18 @@ func (e *ErrorWriter) Write(p []byte) (int, error) {
 	return 0, e.Err
 }
 
+// SyncWriter counts the calls of Sync
+type SyncWriter struct {
+	MockWriter
+	Syncs int
+	Err   error
+}
+
+func (s *SyncWriter) Sync() error {
+	s.Syncs++
+	return s.Err
+}
+
 func TestNewWriter(t *testing.T) {
 	t.Run("Creates new writer with empty writers slice", func(t *testing.T) {
 		w := NewWriter()
@@ -242,8 +254,8 @@ func TestWrite(t *testing.T) {
 			t.Errorf("Write returned error: %v", err)
 		}
 
-		if n != 0 {
-			t.Errorf("Expected 0 bytes written, got %d", n)
+		if n != len(testData) {
+			t.Errorf("Expected %d bytes written, got %d", len(testData), n)
 		}
 
 		if buf1.String() != string(testData) {
@@ -317,16 +329,23 @@ func TestWrite(t *testing.T) {
 		}
 	})
 
-	t.Run("Write lock prevents concurrent access", func(t *testing.T) {
+	t.Run("A blocked writer does not stall the other writers", func(t *testing.T) {
 		w := NewWriter().(*ionWriter)
 
 		// Create a writer that blocks until signaled
 		blockCh := make(chan struct{})
 
+		var blockedMutex sync.Mutex
+		var blocked string
+
 		blockingWriter := &MockWriter{
 			WriteFunc: func(p []byte) (int, error) {
 				// Block until signaled
 				<-blockCh
+
+				blockedMutex.Lock()
+				defer blockedMutex.Unlock()
+				blocked += string(p)
 				return len(p), nil
 			},
 		}
@@ -346,37 +365,117 @@ func TestWrite(t *testing.T) {
 
 		w.AddWriter(normalWriter, blockingWriter)
 
+		var wg sync.WaitGroup
+		wg.Add(1)
 		go func() {
-			w.Write([]byte("test1")) // blocked by second write
+			defer wg.Done()
+			w.Write([]byte("test1")) // blocked by the blocking writer
 		}()
 		time.Sleep(10 * time.Millisecond) // At least the normal writer should have written by now
 
-		bufMutex.Lock()
-		if buf != "test1" {
-			t.Errorf("First write did not complete: got %q", buf)
-		}
-		bufMutex.Unlock()
-
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			w.Write([]byte("test2"))
 		}()
-		time.Sleep(10 * time.Millisecond) // Same wait, but not expecting the second write to complete
+		time.Sleep(10 * time.Millisecond) // The normal writer is not held by the blocking writer
 
 		bufMutex.Lock()
-		if strings.Contains(buf, "test2") {
-			t.Errorf("Second write completed before first write")
+		if buf != "test1test2" {
+			t.Errorf("Second write did not reach the normal writer: got %q", buf)
 		}
 		bufMutex.Unlock()
 
 		// Signal the blocking writer to continue
 		close(blockCh)
-		time.Sleep(10 * time.Millisecond) // Same wait, but now expecting the second write to complete
+		wg.Wait()
 
-		bufMutex.Lock()
-		if buf != "test1test2" {
-			t.Errorf("Second write did not complete: got %q", buf)
+		// the writes of the blocking writer do not overlap
+		if blocked != "test1test2" && blocked != "test2test1" {
+			t.Errorf("Blocking writer did not receive both writes: got %q", blocked)
+		}
+	})
+}
+
+func TestSync(t *testing.T) {
+	oldStderr := os.Stderr
+	defer func() { os.Stderr = oldStderr }()
+
+	t.Run("should sync only the writers which implement Sync", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		syncWriter := &SyncWriter{}
+		buf := &bytes.Buffer{}
+
+		w.AddWriter(buf, syncWriter)
+		w.Sync()
+
+		if syncWriter.Syncs != 1 {
+			t.Errorf("expected the writer to be synced once, but got %d", syncWriter.Syncs)
+		}
+	})
+
+	t.Run("should report the sync errors and continue", func(t *testing.T) {
+		r, pw, _ := os.Pipe()
+		os.Stderr = pw
+
+		w := NewWriter().(*ionWriter)
+		errWriter := &SyncWriter{Err: errors.New("sync error")}
+		syncWriter := &SyncWriter{}
+
+		w.AddWriter(errWriter, syncWriter)
+		w.Sync()
+
+		pw.Close()
+
+		errOutput := make([]byte, 1024)
+		n, _ := r.Read(errOutput)
+
+		if !strings.Contains(string(errOutput[:n]), "Failed to sync the 1° target") {
+			t.Errorf("Expected error message for failed sync, got: %s", errOutput[:n])
+		}
+		if syncWriter.Syncs != 1 {
+			t.Errorf("expected the second writer to be synced once, but got %d", syncWriter.Syncs)
+		}
+	})
+}
+
+func TestWriterStats(t *testing.T) {
+	oldStderr := os.Stderr
+	defer func() { os.Stderr = oldStderr }()
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	t.Run("should count the bytes and the errors of every writer", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		buf := &bytes.Buffer{}
+		errWriter := &ErrorWriter{Err: errors.New("write error")}
+
+		w.AddWriter(buf, errWriter)
+		_, _ = w.Write([]byte("first\n"))
+		_, _ = w.Write([]byte("second\n"))
+
+		stats := w.Stats()
+		if len(stats) != 2 {
+			t.Fatalf("expected the stats of 2 writers, but got %v", stats)
+		}
+		if stats[0] != (WriterStats{Name: "*bytes.Buffer", Bytes: 13}) {
+			t.Errorf("expected 13 bytes written, but got %+v", stats[0])
+		}
+		if stats[1] != (WriterStats{Name: "*logengine.ErrorWriter", State: WriterFailing, Errors: 2}) {
+			t.Errorf("expected 2 errors, but got %+v", stats[1])
+		}
+	})
+
+	t.Run("should forget the stats of a deleted writer", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		buf := &bytes.Buffer{}
+
+		w.AddWriter(buf)
+		_, _ = w.Write([]byte("first\n"))
+		w.DeleteWriter(buf)
+
+		if _, ok := w.states[buf]; ok {
+			t.Errorf("expected the stats of the deleted writer to be removed")
 		}
-		bufMutex.Unlock()
 	})
 }
 
@@ -0,0 +1,208 @@
+package logengine
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WriterState is the health of a writer.
+type WriterState int
+
+const (
+	// WriterHealthy is a writer whose last write succeeded.
+	WriterHealthy WriterState = iota
+	// WriterFailing is a writer whose last write failed.
+	WriterFailing
+	// WriterDisabled is a writer disabled by the circuit breaker,
+	// it is probed again after the cooldown.
+	WriterDisabled
+)
+
+func (s WriterState) String() string {
+	switch s {
+	case WriterHealthy:
+		return "HEALTHY"
+	case WriterFailing:
+		return "FAILING"
+	case WriterDisabled:
+		return "DISABLED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// RetryPolicy retries the writes failed by transient errors, as timeouts and EAGAIN.
+// The backoff is doubled after every attempt, up to MaxBackoff when it is not zero.
+// Zero attempts disables the retries, it is the default.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// CircuitBreaker disables a writer after Failures consecutive failed writes,
+// the writer is probed with the next write after the Cooldown:
+// it is enabled again when the probe succeeds, or disabled for another Cooldown.
+// Zero failures disables the circuit breaker, it is the default.
+type CircuitBreaker struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+// writerState is the options, the health and the metrics of a writer.
+type writerState struct {
+	// the options and the delivery are guarded by the writeLock
+	options WriterOptions
+	async   *asyncDelivery
+
+	// writing is held while an entry is written, so the writes of the writer
+	// do not overlap and the writer is synced between the writes
+	writing sync.Mutex
+
+	// the health and the metrics are guarded by the lock,
+	// they are updated by the delivery goroutine of the writer
+	lock     sync.Mutex
+	state    WriterState
+	failures int
+	openedAt time.Time
+
+	bytes   uint64
+	errors  uint64
+	dropped uint64
+}
+
+// allow reports whether the writer can be written, a disabled writer is allowed after the cooldown.
+func (s *writerState) allow(now time.Time, breaker CircuitBreaker) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.state != WriterDisabled || breaker.Failures <= 0 {
+		return true
+	}
+	return now.Sub(s.openedAt) >= breaker.Cooldown
+}
+
+// record records the bytes written and the error of a write,
+// it returns true when the error disables the writer.
+func (s *writerState) record(n int, err error, now time.Time, breaker CircuitBreaker) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.bytes += uint64(n)
+	if err == nil {
+		s.succeed()
+		return false
+	}
+
+	s.errors++
+	return s.fail(now, breaker)
+}
+
+// drop records the entries dropped because the buffer of the writer was full
+// or because the writer was disabled.
+func (s *writerState) drop(n int) {
+	if n == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dropped += uint64(n)
+}
+
+func (s *writerState) stats(name string) WriterStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return WriterStats{Name: name, State: s.state, Bytes: s.bytes, Errors: s.errors, Dropped: s.dropped}
+}
+
+// succeed records a successful write. The lock must be held.
+func (s *writerState) succeed() {
+	s.state = WriterHealthy
+	s.failures = 0
+}
+
+// fail records a failed write, it returns true when the failure disables the writer.
+// The lock must be held.
+func (s *writerState) fail(now time.Time, breaker CircuitBreaker) bool {
+	s.failures++
+
+	if s.state == WriterDisabled {
+		// the probe failed
+		s.openedAt = now
+		return false
+	}
+
+	if breaker.Failures > 0 && s.failures >= breaker.Failures {
+		s.state = WriterDisabled
+		s.openedAt = now
+		return true
+	}
+
+	s.state = WriterFailing
+	return false
+}
+
+// writeRetry writes p to the writer, retrying the rest of p after a transient error.
+func (i *ionWriter) writeRetry(w io.Writer, p []byte, cfg writerConfig) (int, error) {
+	retry := cfg.retry
+	backoff := retry.Backoff
+
+	n, err := writeTimeFormat(w, p, cfg.timeFormat)
+	n = max(n, 0)
+
+	for attempt := 0; err != nil && attempt < retry.Attempts && isTransient(err); attempt++ {
+		i.sleep(backoff)
+
+		backoff *= 2
+		if retry.MaxBackoff > 0 {
+			backoff = min(backoff, retry.MaxBackoff)
+		}
+
+		var written int
+		written, err = writeTimeFormat(w, p[n:], cfg.timeFormat)
+		n += max(written, 0)
+	}
+
+	return n, err
+}
+
+// isTransient reports whether the error may not happen again on a new write.
+func isTransient(err error) bool {
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.ErrShortWrite) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
+
+// SetRetryPolicy sets the retries of the writes failed by transient errors.
+func (i *ionWriter) SetRetryPolicy(retry RetryPolicy) {
+	i.configLock.Lock()
+	defer i.configLock.Unlock()
+	i.retry = retry
+}
+
+// SetCircuitBreaker sets when the failing writers are disabled.
+func (i *ionWriter) SetCircuitBreaker(breaker CircuitBreaker) {
+	i.configLock.Lock()
+	defer i.configLock.Unlock()
+	i.breaker = breaker
+}
+
+// SetErrorHandler sets the function called with the writer and the error of every failed write,
+// in place of printing the error to stderr. A nil handler restores the printing.
+func (i *ionWriter) SetErrorHandler(handler func(w io.Writer, err error)) {
+	i.configLock.Lock()
+	defer i.configLock.Unlock()
+	i.errorHandler = handler
+}
@@ -0,0 +1,217 @@
+package logengine
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails the first writes with the error.
+type flakyWriter struct {
+	fails  int
+	err    error
+	writes int
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	f.writes++
+	if f.fails > 0 {
+		f.fails--
+		return 0, f.err
+	}
+	return len(p), nil
+}
+
+func TestWriterRetry(t *testing.T) {
+	newWriter := func(retry RetryPolicy) (*ionWriter, *[]time.Duration) {
+		w := NewWriter().(*ionWriter)
+		var sleeps []time.Duration
+		w.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+		w.SetRetryPolicy(retry)
+		w.SetErrorHandler(func(io.Writer, error) {})
+		return w, &sleeps
+	}
+
+	t.Run("should retry the transient errors with backoff", func(t *testing.T) {
+		w, sleeps := newWriter(RetryPolicy{Attempts: 3, Backoff: 10 * time.Millisecond, MaxBackoff: 15 * time.Millisecond})
+		flaky := &flakyWriter{fails: 2, err: syscall.EAGAIN}
+		w.AddWriter(flaky)
+
+		if _, err := w.Write([]byte("data")); err != nil {
+			t.Errorf("expected no error, but got %v", err)
+		}
+		if flaky.writes != 3 {
+			t.Errorf("expected 3 writes, but got %v", flaky.writes)
+		}
+		if len(*sleeps) != 2 || (*sleeps)[0] != 10*time.Millisecond || (*sleeps)[1] != 15*time.Millisecond {
+			t.Errorf("expected the backoff to be doubled up to the max, but got %v", *sleeps)
+		}
+		if stats := w.Stats()[0]; stats.State != WriterHealthy || stats.Bytes != 4 {
+			t.Errorf("expected a healthy writer with 4 bytes, but got %+v", stats)
+		}
+	})
+
+	t.Run("should not retry the permanent errors", func(t *testing.T) {
+		w, sleeps := newWriter(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+		flaky := &flakyWriter{fails: 1, err: os.ErrClosed}
+		w.AddWriter(flaky)
+
+		_, err := w.Write([]byte("data"))
+		if !errors.Is(err, os.ErrClosed) {
+			t.Errorf("expected the write error, but got %v", err)
+		}
+		if flaky.writes != 1 || len(*sleeps) != 0 {
+			t.Errorf("expected no retry, but got %v writes", flaky.writes)
+		}
+		if stats := w.Stats()[0]; stats.State != WriterFailing || stats.Errors != 1 {
+			t.Errorf("expected a failing writer with 1 error, but got %+v", stats)
+		}
+	})
+}
+
+func TestWriterRetryLock(t *testing.T) {
+	t.Run("should not hold the other writers while retrying", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		release := make(chan struct{})
+		w.sleep = func(time.Duration) { <-release }
+		w.SetRetryPolicy(RetryPolicy{Attempts: 1, Backoff: time.Second})
+		w.SetErrorHandler(func(io.Writer, error) {})
+
+		fast := &mockBufferWriter{}
+		flaky := &flakyWriter{fails: 1, err: syscall.EAGAIN}
+		w.AddWriter(fast, flaky)
+
+		retried := make(chan struct{})
+		go func() {
+			defer close(retried)
+			_, _ = w.Write([]byte("first"))
+		}()
+
+		// waits for the first write to be retrying
+		for w.Stats()[0].Bytes == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		written := make(chan struct{})
+		go func() {
+			defer close(written)
+			_, _ = w.Write([]byte("second"))
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for fast.String() != "firstsecond" && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := fast.String(); got != "firstsecond" {
+			t.Errorf("expected the fast writer to get both entries while retrying, but got %q", got)
+		}
+
+		close(release)
+		<-retried
+		<-written
+		if flaky.writes != 3 {
+			t.Errorf("expected the retried entry and the next one, but got %v writes", flaky.writes)
+		}
+	})
+}
+
+func TestWriterCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w := NewWriter().(*ionWriter)
+	w.now = func() time.Time { return now }
+	w.SetCircuitBreaker(CircuitBreaker{Failures: 2, Cooldown: time.Minute})
+	w.SetErrorHandler(func(io.Writer, error) {})
+
+	oldStderr := os.Stderr
+	defer func() { os.Stderr = oldStderr }()
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	flaky := &flakyWriter{fails: 3, err: os.ErrClosed}
+	w.AddWriter(flaky)
+
+	t.Run("should disable the writer after the consecutive failures", func(t *testing.T) {
+		_, _ = w.Write([]byte("first"))
+		_, _ = w.Write([]byte("second"))
+		_, _ = w.Write([]byte("third"))
+
+		if flaky.writes != 2 {
+			t.Errorf("expected the disabled writer to be skipped, but got %v writes", flaky.writes)
+		}
+		if stats := w.Stats()[0]; stats.State != WriterDisabled || stats.Dropped != 1 {
+			t.Errorf("expected a disabled writer with 1 dropped entry, but got %+v", stats)
+		}
+	})
+
+	t.Run("should disable the writer again when the probe fails", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, _ = w.Write([]byte("probe"))
+		_, _ = w.Write([]byte("skipped"))
+
+		if flaky.writes != 3 {
+			t.Errorf("expected one probe write, but got %v writes", flaky.writes)
+		}
+		if stats := w.Stats()[0]; stats.State != WriterDisabled || stats.Dropped != 2 {
+			t.Errorf("expected a disabled writer with 2 dropped entries, but got %+v", stats)
+		}
+	})
+
+	t.Run("should enable the writer when the probe succeeds", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, _ = w.Write([]byte("probe"))
+		_, _ = w.Write([]byte("written"))
+
+		if flaky.writes != 5 {
+			t.Errorf("expected the writer to be enabled, but got %v writes", flaky.writes)
+		}
+		if state := w.Stats()[0].State; state != WriterHealthy {
+			t.Errorf("expected a healthy writer, but got %v", state)
+		}
+	})
+}
+
+func TestWriterErrorHandler(t *testing.T) {
+	t.Run("should call the handler with the writer and the error", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		errWriter := &ErrorWriter{Err: errors.New("write error")}
+		w.AddWriter(errWriter)
+
+		var failed io.Writer
+		var stats []WriterStats
+		w.SetErrorHandler(func(fw io.Writer, err error) {
+			failed = fw
+			// the lock is released before the handler is called
+			stats = w.Stats()
+		})
+
+		_, _ = w.Write([]byte("data"))
+
+		if failed != errWriter {
+			t.Errorf("expected the failed writer, but got %v", failed)
+		}
+		if len(stats) != 1 || stats[0].Errors != 1 {
+			t.Errorf("expected the stats of the failed writer, but got %v", stats)
+		}
+	})
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{syscall.EAGAIN, true},
+		{io.ErrShortWrite, true},
+		{os.ErrDeadlineExceeded, true},
+		{&os.PathError{Op: "write", Path: "x", Err: syscall.EINTR}, true},
+		{os.ErrClosed, false},
+		{errors.New("broken"), false},
+	} {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("expected isTransient(%v) to be %v, but got %v", tt.err, tt.want, got)
+		}
+	}
+}
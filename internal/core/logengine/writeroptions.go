@@ -0,0 +1,99 @@
+package logengine
+
+import (
+	"io"
+	"reflect"
+)
+
+// Formatter formats the JSON entries for a writer, as a pretty output for terminals.
+// An entry is formatted once per distinct formatter, the formatters are compared
+// with each other, as pointers. A formatter which cannot be compared, as a func
+// or a struct with a slice, formats the entries of its writer alone.
+type Formatter interface {
+	Format(entry []byte) ([]byte, error)
+}
+
+// WriterOptions are the levels written by a writer and their format.
+type WriterOptions struct {
+	// Levels reports whether the entries of the level are written, nil writes every level.
+	Levels func(level Level) bool
+	// Formatter formats the entries, nil writes the JSON entries.
+	Formatter Formatter
+	// Buffer is the size of the buffer of the entries written from the own goroutine of the writer,
+	// so a slow writer does not stall the others. Zero writes the entries from the caller.
+	Buffer int
+	// Policy is what is done with the entries when the buffer is full, SpillToDisk blocks as Block.
+	Policy BackpressurePolicy
+}
+
+// formatterRef references a formatter which cannot be compared,
+// the pointer is compared in place of the formatter.
+type formatterRef struct {
+	Formatter
+}
+
+// isComparable reports whether the formatter can be compared without a panic,
+// the comparison panics on the values of a func, a map or a slice, even inside a struct.
+func isComparable(f Formatter) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return reflect.TypeOf(f).Comparable() && f == f
+}
+
+// formattedEntry is an entry formatted by a formatter, kept while the entry is written.
+type formattedEntry struct {
+	formatter Formatter
+	entry     []byte
+	err       error
+}
+
+func (o WriterOptions) accept(level Level) bool {
+	return o.Levels == nil || o.Levels(level)
+}
+
+// SetWriterOptions sets the levels, the format and the buffer of the writer,
+// the options can be set before the writer is added.
+func (i *ionWriter) SetWriterOptions(w io.Writer, options WriterOptions) {
+	i.writeLock.Lock()
+	defer i.writeLock.Unlock()
+
+	options.Formatter = i.formatterFor(options.Formatter)
+	if options.Formatter != nil && !isComparable(options.Formatter) {
+		options.Formatter = &formatterRef{options.Formatter}
+	}
+
+	state := i.writerState(w)
+	state.stopAsync()
+	state.options = options
+
+	if options.Buffer > 0 {
+		state.async = i.startAsync(w, state, options.Buffer, options.Policy)
+	}
+}
+
+// format returns the entry formatted by the formatter, the entry is formatted once
+// for every formatter until the formatted entries are cleared. The writeLock must be held.
+func (i *ionWriter) format(formatter Formatter, entry []byte) ([]byte, error) {
+	if formatter == nil {
+		return entry, nil
+	}
+
+	for _, f := range i.formatted {
+		if f.formatter == formatter {
+			return f.entry, f.err
+		}
+	}
+
+	formatted, err := formatter.Format(entry)
+	i.formatted = append(i.formatted, formattedEntry{formatter: formatter, entry: formatted, err: err})
+	return formatted, err
+}
+
+// clearFormatted forgets the formatted entries, it must be called before the writeLock is released.
+func (i *ionWriter) clearFormatted() {
+	clear(i.formatted)
+	i.formatted = i.formatted[:0]
+}
@@ -0,0 +1,150 @@
+package logengine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countFormatter upper cases the entries and counts the formatted entries.
+type countFormatter struct {
+	calls int
+	err   error
+}
+
+func (c *countFormatter) Format(entry []byte) ([]byte, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return bytes.ToUpper(entry), nil
+}
+
+// funcFormatter is a formatter which cannot be compared.
+type funcFormatter func(entry []byte) ([]byte, error)
+
+func (f funcFormatter) Format(entry []byte) ([]byte, error) {
+	return f(entry)
+}
+
+// sliceFormatter is a formatter which cannot be compared, as it holds a slice.
+type sliceFormatter struct {
+	prefix []byte
+}
+
+func (s sliceFormatter) Format(entry []byte) ([]byte, error) {
+	return append(bytes.Clone(s.prefix), entry...), nil
+}
+
+func TestWriterOptions(t *testing.T) {
+	atLeast := func(min Level) func(Level) bool {
+		return func(level Level) bool { return level >= min }
+	}
+
+	t.Run("should write the entries of the accepted levels", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		all := &bytes.Buffer{}
+		alerts := &bytes.Buffer{}
+
+		w.AddWriter(all, alerts)
+		w.SetWriterOptions(alerts, WriterOptions{Levels: atLeast(Error)})
+
+		_ = w.WriteEntry(Info, []byte("info\n"))
+		_ = w.WriteEntry(Error, []byte("error\n"))
+
+		if all.String() != "info\nerror\n" {
+			t.Errorf("expected every entry, but got %q", all.String())
+		}
+		if alerts.String() != "error\n" {
+			t.Errorf("expected only the error entry, but got %q", alerts.String())
+		}
+	})
+
+	t.Run("should format the entry once per formatter", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		formatter := &countFormatter{}
+		json := &bytes.Buffer{}
+		pretty1 := &bytes.Buffer{}
+		pretty2 := &bytes.Buffer{}
+
+		w.SetWriterOptions(pretty1, WriterOptions{Formatter: formatter})
+		w.SetWriterOptions(pretty2, WriterOptions{Formatter: formatter})
+		w.AddWriter(json, pretty1, pretty2)
+
+		_ = w.WriteEntry(Info, []byte("entry\n"))
+		_ = w.WriteEntry(Info, []byte("next\n"))
+
+		if formatter.calls != 2 {
+			t.Errorf("expected 2 formatted entries, but got %v", formatter.calls)
+		}
+		if json.String() != "entry\nnext\n" {
+			t.Errorf("expected the entries as they are, but got %q", json.String())
+		}
+		if pretty1.String() != "ENTRY\nNEXT\n" || pretty2.String() != "ENTRY\nNEXT\n" {
+			t.Errorf("expected the formatted entries, but got %q and %q", pretty1.String(), pretty2.String())
+		}
+		if len(w.formatted) != 0 {
+			t.Errorf("expected the formatted entries to be cleared, but got %v", w.formatted)
+		}
+	})
+
+	t.Run("should format with the formatters which cannot be compared", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		upper := &bytes.Buffer{}
+		prefixed := &bytes.Buffer{}
+		other := &bytes.Buffer{}
+
+		w.SetWriterOptions(upper, WriterOptions{Formatter: funcFormatter(func(entry []byte) ([]byte, error) {
+			return bytes.ToUpper(entry), nil
+		})})
+		w.SetWriterOptions(prefixed, WriterOptions{Formatter: sliceFormatter{prefix: []byte("> ")}})
+		w.SetWriterOptions(other, WriterOptions{Formatter: sliceFormatter{prefix: []byte("# ")}})
+		w.AddWriter(upper, prefixed, other)
+
+		if err := w.WriteEntry(Info, []byte("entry\n")); err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+
+		if upper.String() != "ENTRY\n" || prefixed.String() != "> entry\n" || other.String() != "# entry\n" {
+			t.Errorf("expected every entry formatted by its formatter, but got %q, %q and %q", upper, prefixed, other)
+		}
+	})
+
+	t.Run("should report the errors of the formatter", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		formatErr := errors.New("invalid entry")
+		buf := &bytes.Buffer{}
+
+		var handled error
+		w.SetErrorHandler(func(_ io.Writer, err error) { handled = err })
+		w.SetWriterOptions(buf, WriterOptions{Formatter: &countFormatter{err: formatErr}})
+		w.AddWriter(buf)
+
+		err := w.WriteEntry(Info, []byte("entry\n"))
+
+		if !errors.Is(err, formatErr) || handled != formatErr {
+			t.Errorf("expected the formatter error, but got %v and %v", err, handled)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected nothing written, but got %q", buf.String())
+		}
+		if stats := w.Stats()[0]; stats.Errors != 1 {
+			t.Errorf("expected 1 error, but got %+v", stats)
+		}
+	})
+
+	t.Run("should write every level with Write", func(t *testing.T) {
+		w := NewWriter().(*ionWriter)
+		buf := &bytes.Buffer{}
+
+		w.SetWriterOptions(buf, WriterOptions{Levels: atLeast(Fatal)})
+		w.AddWriter(buf)
+
+		_, _ = w.Write([]byte("entry\n"))
+
+		if buf.String() != "entry\n" {
+			t.Errorf("expected the entry, but got %q", buf.String())
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 
 	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/filesystem"
 )
@@ -16,12 +17,24 @@ type rotationEngine struct {
 	folder        string
 	maxFolderSize uint
 	rotation      PeriodicRotation
+
+	rotations    atomic.Uint64
+	deletedFiles atomic.Uint64
 }
 
 type IRotationEngine interface {
 	io.Writer
+	Sync() error
 	AutoChecks()
 	CloseLogFile()
+	Stats() Stats
+}
+
+// Stats are the number of rotations performed and of files deleted
+// to keep the folder below its max size.
+type Stats struct {
+	Rotations    uint64
+	DeletedFiles uint64
 }
 
 func NewRotationEngine(folder string, maxFolderSize uint, rotation PeriodicRotation) IRotationEngine {
@@ -53,6 +66,25 @@ func (r *rotationEngine) Write(p []byte) (n int, err error) {
 	return r.logFile.Write(p)
 }
 
+// Sync commits the content of the log file to the storage.
+func (r *rotationEngine) Sync() error {
+	if r.logFile == nil {
+		return ErrLogFileNotSet
+	}
+
+	s, ok := r.logFile.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+
+	return s.Sync()
+}
+
+// Stats returns the number of rotations performed and of deleted files.
+func (r *rotationEngine) Stats() Stats {
+	return Stats{Rotations: r.rotations.Load(), DeletedFiles: r.deletedFiles.Load()}
+}
+
 func (r *rotationEngine) AutoChecks() {
 	r.autoRotate()
 	r.autoCheckFolderSize()
@@ -108,6 +140,7 @@ func (r *rotationEngine) autoRotate() {
 
 	if r.checkRotation(fileDate) {
 		r.createNewFile()
+		r.rotations.Add(1)
 		return
 	}
 
@@ -147,6 +180,7 @@ func (r *rotationEngine) autoCheckFolderSize() {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
+	r.deletedFiles.Add(1)
 
 	// check if it need to create a new file
 	files, err := r.getAllfiles()
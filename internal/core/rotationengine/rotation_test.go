@@ -122,6 +122,46 @@ func TestWrite(t *testing.T) {
 	})
 }
 
+func TestSync(t *testing.T) {
+	folderName := "rotation_sync"
+	maxFolderSize := GB
+	rotation := Daily
+
+	t.Run("should failure when sync a nil logfile", func(t *testing.T) {
+		r := NewRotationEngine(folderName, maxFolderSize, rotation)
+		_r, ok := r.(*rotationEngine)
+		if !ok {
+			t.Fatal("NewRotationEngine() did not return a instace of rotation engine")
+		}
+
+		_r.logFile = nil
+
+		if err := r.Sync(); err != ErrLogFileNotSet {
+			t.Errorf("expected error to be %q, but got %q", ErrLogFileNotSet, err)
+		}
+
+		if err := os.RemoveAll(folderName); err != nil {
+			t.Error("expected remove all file and the directory")
+		}
+	})
+
+	t.Run("should sync the logfile", func(t *testing.T) {
+		r := NewRotationEngine(folderName, maxFolderSize, rotation)
+
+		if _, err := r.Write([]byte("Hello World")); err != nil {
+			t.Errorf("expected no error, but got %q", err)
+		}
+		if err := r.Sync(); err != nil {
+			t.Errorf("expected no error, but got %q", err)
+		}
+
+		r.CloseLogFile()
+		if err := os.RemoveAll(folderName); err != nil {
+			t.Error("expected remove all file and the directory")
+		}
+	})
+}
+
 func TestAutoChecks(t *testing.T) {
 
 }
@@ -326,6 +366,10 @@ func TestAutoRotate(t *testing.T) {
 				t.Error("expected the logfile set")
 			}
 
+			if rotations := _r.Stats().Rotations; rotations != 1 {
+				t.Errorf("expected 1 rotation, but got %v", rotations)
+			}
+
 			fileName, err = _r.getMostRecentLogFile()
 			if err != nil {
 				t.Errorf("expected no error, but got %q", err)
@@ -686,6 +730,10 @@ func TestAutoCheckFolderSize(t *testing.T) {
 			t.Error("expected the logfile set")
 		}
 
+		if stats := _r.Stats(); stats.DeletedFiles != 1 {
+			t.Errorf("expected 1 deleted file, but got %v", stats.DeletedFiles)
+		}
+
 		fileName, err = _r.getMostRecentLogFile()
 		if err != nil {
 			t.Errorf("expected no error, but got %q", err)
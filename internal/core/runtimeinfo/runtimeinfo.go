@@ -5,36 +5,189 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 )
 
+// CallerInfo is the caller of a log function, Package is the name of its package
+// and PackagePath is the import path of the package.
 type CallerInfo struct {
-	File     string
-	Package  string
-	Function string
-	Line     int
+	File        string
+	Package     string
+	PackagePath string
+	Function    string
+	Line        int
 }
 
+// StackFrame is a frame of a stack trace, the function is the full name of the function
+// and the file is the full path of the file.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// maxStackDepth limits the frames recorded in a stack trace.
+const maxStackDepth = 64
+
+// callerCache keeps the caller information by program counter,
+// a call site is resolved once and its strings are shared by all its entries.
+var callerCache = struct {
+	sync.RWMutex
+	infos map[uintptr]CallerInfo
+}{infos: map[uintptr]CallerInfo{}}
+
+// GetCallerInfo returns the caller information of the frame,
+// skip is counted as in runtime.Caller.
 func GetCallerInfo(skip int) CallerInfo {
-	pc, file, line, ok := runtime.Caller(skip)
-	if !ok {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) == 0 {
 		fmt.Fprint(os.Stderr, "Failed to get caller information\n")
 		return CallerInfo{}
 	}
 
-	fileLastSlashIndex := strings.LastIndexByte(file, '/')
+	return GetCallerInfoFromPC(pcs[0])
+}
+
+// GetCallerInfoFromPC returns the caller information of a program counter,
+// like the one recorded by log/slog.
+func GetCallerInfoFromPC(pc uintptr) CallerInfo {
+	if pc == 0 {
+		return CallerInfo{}
+	}
+
+	callerCache.RLock()
+	info, ok := callerCache.infos[pc]
+	callerCache.RUnlock()
+	if ok {
+		return info
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	info = newCallerInfo(frame.Function, frame.File, frame.Line)
+
+	callerCache.Lock()
+	callerCache.infos[pc] = info
+	callerCache.Unlock()
+
+	return info
+}
+
+// GetCallerInfoOutside returns the caller information of the first function,
+// after skipping the given number of frames, which is not in the package path.
+// It is used to find the caller of a package which calls the log functions, like log.
+func GetCallerInfoOutside(skip int, pkgPath string) CallerInfo {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && packagePath(frame.Function) != pkgPath {
+			return newCallerInfo(frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+
+	return CallerInfo{}
+}
+
+// GetStack returns the stack trace of the goroutine,
+// its first frame is the same one returned by GetCallerInfo with the same skip.
+func GetStack(skip int) []StackFrame {
+	return stackFrames(callers(skip))
+}
+
+// GetStackFromPC returns the stack trace of the goroutine from the frame of the program counter,
+// like the one recorded by log/slog. It returns nil when the frame is not in the stack.
+func GetStackFromPC(pc uintptr) []StackFrame {
+	pcs := callers(0)
 
-	// Get function name
-	fullFuncName := runtime.FuncForPC(pc).Name()
+	for i := range pcs {
+		if pcs[i] == pc {
+			return stackFrames(pcs[i:])
+		}
+	}
+
+	return nil
+}
+
+// GetStackOutside returns the stack trace of the goroutine from the frame of the first function,
+// after skipping the given number of frames, which is not in the package path.
+// Its first frame is the same one returned by GetCallerInfoOutside.
+func GetStackOutside(skip int, pkgPath string) []StackFrame {
+	stack := GetStack(skip + 1)
+
+	for i, frame := range stack {
+		if frame.Function != "" && packagePath(frame.Function) != pkgPath {
+			return stack[i:]
+		}
+	}
+
+	return nil
+}
+
+// callers returns the program counters of the stack,
+// skip is counted from the caller of callers, as in runtime.Caller.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+func stackFrames(pcs []uintptr) []StackFrame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]StackFrame, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
+
+// packagePath returns the import path of the package of a full function name.
+func packagePath(fullFuncName string) string {
+	lastSlashIndex := strings.LastIndexByte(fullFuncName, '/')
+	dotIndex := strings.IndexByte(fullFuncName[lastSlashIndex+1:], '.')
+	if dotIndex < 0 {
+		return fullFuncName
+	}
+	return fullFuncName[:lastSlashIndex+1+dotIndex]
+}
+
+func newCallerInfo(fullFuncName string, file string, line int) CallerInfo {
+	fileLastSlashIndex := strings.LastIndexByte(file, '/')
 
 	lastSlashIndex := strings.LastIndexByte(fullFuncName, '/')
 
 	fistDotIndex := strings.IndexByte(fullFuncName[lastSlashIndex+1:], '.')
+	if fistDotIndex < 0 {
+		return CallerInfo{
+			File:     file[fileLastSlashIndex+1:],
+			Function: fullFuncName,
+			Line:     line,
+		}
+	}
 	pkgEnd := lastSlashIndex + 1 + fistDotIndex
 
 	return CallerInfo{
-		File:     file[fileLastSlashIndex+1:],
-		Package:  fullFuncName[lastSlashIndex+1 : pkgEnd],
-		Function: fullFuncName[pkgEnd+1:],
-		Line:     line,
+		File:        file[fileLastSlashIndex+1:],
+		Package:     fullFuncName[lastSlashIndex+1 : pkgEnd],
+		PackagePath: fullFuncName[:pkgEnd],
+		Function:    fullFuncName[pkgEnd+1:],
+		Line:        line,
 	}
 }
@@ -2,6 +2,7 @@ package runtimeinfo
 
 import (
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -124,3 +125,161 @@ func TestGetCallerInfoInDifferentFile(t *testing.T) {
 		}
 	})
 }
+
+func TestGetCallerInfoFromPC(t *testing.T) {
+	t.Run("should return the information of the program counter", func(t *testing.T) {
+		var pcs [1]uintptr
+		runtime.Callers(1, pcs[:])
+
+		info := GetCallerInfoFromPC(pcs[0])
+		expected := GetCallerInfo(1)
+
+		if info.File != expected.File || info.Package != expected.Package || info.Function != expected.Function {
+			t.Errorf("expected caller info to be %+v, but got %+v", expected, info)
+		}
+
+		if info.Line != expected.Line-3 {
+			t.Errorf("expected line to be %d, but got %d", expected.Line-3, info.Line)
+		}
+	})
+
+	t.Run("should return empty caller info when program counter is zero", func(t *testing.T) {
+		if info := GetCallerInfoFromPC(0); info != (CallerInfo{}) {
+			t.Errorf("expected empty caller info, but got %+v", info)
+		}
+	})
+}
+
+func TestNewCallerInfo(t *testing.T) {
+	t.Run("should split the package and the function", func(t *testing.T) {
+		info := newCallerInfo("github.com/IonicHealthUsa/ionlog/internal/billing.(*Invoice).Charge", "/src/billing/invoice.go", 10)
+
+		expected := CallerInfo{
+			File:        "invoice.go",
+			Package:     "billing",
+			PackagePath: "github.com/IonicHealthUsa/ionlog/internal/billing",
+			Function:    "(*Invoice).Charge",
+			Line:        10,
+		}
+		if info != expected {
+			t.Errorf("expected caller info to be %+v, but got %+v", expected, info)
+		}
+	})
+
+	t.Run("should not panic when the function has no package", func(t *testing.T) {
+		info := newCallerInfo("", "file.go", 1)
+
+		expected := CallerInfo{File: "file.go", Line: 1}
+		if info != expected {
+			t.Errorf("expected caller info to be %+v, but got %+v", expected, info)
+		}
+	})
+}
+
+func TestPackagePath(t *testing.T) {
+	testCases := [...]struct {
+		fullFuncName string
+		expected     string
+	}{
+		{fullFuncName: "log.(*Logger).output", expected: "log"},
+		{fullFuncName: "main.main", expected: "main"},
+		{fullFuncName: "github.com/IonicHealthUsa/ionlog.(*Logger).Info", expected: "github.com/IonicHealthUsa/ionlog"},
+		{fullFuncName: "github.com/a/b.c/d.Func.func1", expected: "github.com/a/b.c/d"},
+		{fullFuncName: "nodot", expected: "nodot"},
+	}
+
+	for _, tt := range testCases {
+		if path := packagePath(tt.fullFuncName); path != tt.expected {
+			t.Errorf("expected package path of %q to be %q, but got %q", tt.fullFuncName, tt.expected, path)
+		}
+	}
+}
+
+func outsideHelper() CallerInfo {
+	return GetCallerInfoOutside(1, "github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo")
+}
+
+func TestGetCallerInfoOutside(t *testing.T) {
+	t.Run("should skip the frames of the package", func(t *testing.T) {
+		info := outsideHelper()
+
+		if info.Package != "testing" {
+			t.Errorf("expected the first caller outside the package to be on testing, but got %+v", info)
+		}
+	})
+
+	t.Run("should return the caller when it is not in the package", func(t *testing.T) {
+		info := GetCallerInfoOutside(1, "log")
+
+		if info.Function != "TestGetCallerInfoOutside.func2" {
+			t.Errorf("expected function to be %q, but got %q", "TestGetCallerInfoOutside.func2", info.Function)
+		}
+	})
+}
+
+func TestGetStack(t *testing.T) {
+	t.Run("should start at the frame returned by GetCallerInfo", func(t *testing.T) {
+		stack, info := GetStack(1), GetCallerInfo(1)
+
+		if len(stack) < 2 {
+			t.Fatalf("expected the stack to have the test and the testing frames, but got %+v", stack)
+		}
+		if !strings.HasSuffix(stack[0].Function, "."+info.Function) || filepath.Base(stack[0].File) != info.File || stack[0].Line != info.Line {
+			t.Errorf("expected the first frame to be %+v, but got %+v", info, stack[0])
+		}
+		if !strings.HasPrefix(stack[1].Function, "testing.") {
+			t.Errorf("expected the second frame to be on testing, but got %+v", stack[1])
+		}
+	})
+
+	t.Run("should limit the depth of the stack", func(t *testing.T) {
+		var recurse func(n int) []StackFrame
+		recurse = func(n int) []StackFrame {
+			if n == 0 {
+				return GetStack(1)
+			}
+			return recurse(n - 1)
+		}
+
+		if stack := recurse(2 * maxStackDepth); len(stack) != maxStackDepth {
+			t.Errorf("expected the stack to have %d frames, but got %d", maxStackDepth, len(stack))
+		}
+	})
+}
+
+// pcHelper records the program counter of its caller, as log/slog does
+func pcHelper() ([]StackFrame, CallerInfo) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	return GetStackFromPC(pcs[0]), GetCallerInfoFromPC(pcs[0])
+}
+
+func TestGetStackFromPC(t *testing.T) {
+	t.Run("should start at the frame of the program counter", func(t *testing.T) {
+		stack, info := pcHelper()
+
+		if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "."+info.Function) || stack[0].Line != info.Line {
+			t.Errorf("expected the first frame to be %+v, but got %+v", info, stack)
+		}
+	})
+
+	t.Run("should return nil when the program counter is not in the stack", func(t *testing.T) {
+		if stack := GetStackFromPC(0); stack != nil {
+			t.Errorf("expected no stack, but got %+v", stack)
+		}
+	})
+}
+
+func stackOutsideHelper() []StackFrame {
+	return GetStackOutside(1, "github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo")
+}
+
+func TestGetStackOutside(t *testing.T) {
+	t.Run("should skip the frames of the package", func(t *testing.T) {
+		stack := stackOutsideHelper()
+
+		if len(stack) == 0 || !strings.HasPrefix(stack[0].Function, "testing.") {
+			t.Errorf("expected the first frame outside the package to be on testing, but got %+v", stack)
+		}
+	})
+}
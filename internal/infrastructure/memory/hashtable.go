@@ -2,39 +2,75 @@
 package memory
 
 import (
+	"container/list"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cespare/xxhash"
 )
 
 type recordUnity struct {
 	MsgHash uint64
+
+	// count is the number of times the record was checked
+	count atomic.Uint64
+	// lastEmitted is the unix time in nanoseconds of the last emitted log, zero when never emitted
+	lastEmitted atomic.Int64
+
+	// usedAt and elem are guarded by the lock of the memory
+	usedAt time.Time
+	elem   *list.Element
 }
 
+// recordMemory keeps the records in a map, and in a list ordered from
+// the most to the least recently used record to evict the least recently used ones.
 type recordMemory struct {
-	records map[uint64]*recordUnity
-	mu      sync.Mutex
+	records    map[uint64]*recordUnity
+	usage      *list.List
+	maxRecords int
+	ttl        time.Duration
+	evictions  uint64
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// Stats are the metrics of the memory.
+type Stats struct {
+	Records   int
+	Evictions uint64
 }
 
 type IRecordUnity interface {
 	GetMsgHash() uint64
 	SetMsgHash(msg uint64)
+	IncCount() uint64
+	GetCount() uint64
+	GetLastEmitted() time.Time
+	CompareAndSwapLastEmitted(old time.Time, new time.Time) bool
 }
 
 type IRecordMemory interface {
 	AddRecord(id uint64, msg string) error
+	LoadOrAddRecord(id uint64, msg string) (record IRecordUnity, added bool)
 	RemoveRecord(id uint64)
 	GetRecord(id uint64) IRecordUnity
+	SetMaxRecords(max int)
+	SetTTL(ttl time.Duration)
+	Reset()
+	Stats() Stats
 }
 
 func NewRecordMemory() IRecordMemory {
 	return &recordMemory{
 		records: make(map[uint64]*recordUnity),
+		usage:   list.New(),
+		now:     time.Now,
 	}
 }
 
-func (r recordUnity) GetMsgHash() uint64 {
+func (r *recordUnity) GetMsgHash() uint64 {
 	return r.MsgHash
 }
 
@@ -42,6 +78,37 @@ func (r *recordUnity) SetMsgHash(msg uint64) {
 	r.MsgHash = msg
 }
 
+// IncCount increments the count of the record and returns the new count.
+func (r *recordUnity) IncCount() uint64 {
+	return r.count.Add(1)
+}
+
+func (r *recordUnity) GetCount() uint64 {
+	return r.count.Load()
+}
+
+// GetLastEmitted returns the time of the last emitted log, it is zero when never emitted.
+func (r *recordUnity) GetLastEmitted() time.Time {
+	nano := r.lastEmitted.Load()
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nano)
+}
+
+// CompareAndSwapLastEmitted sets the time of the last emitted log to new
+// only when it is still old, it reports whether the time was set.
+func (r *recordUnity) CompareAndSwapLastEmitted(old time.Time, new time.Time) bool {
+	return r.lastEmitted.CompareAndSwap(unixNano(old), unixNano(new))
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
 func GenHash(s string) uint64 {
 	return xxhash.Sum64String(s)
 }
@@ -59,6 +126,53 @@ func (r *recordMemory) AddRecord(id uint64, msg string) error {
 	return nil
 }
 
+// LoadOrAddRecord returns the record of the id, adding a new record
+// with the message when it does not exist. added reports whether the record was added.
+func (r *recordMemory) LoadOrAddRecord(id uint64, msg string) (IRecordUnity, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record := r.useRecord(id); record != nil {
+		return record, false
+	}
+
+	record := &recordUnity{MsgHash: GenHash(msg)}
+	r.addRecord(id, record)
+	return record, true
+}
+
+// SetMaxRecords sets the max number of records, the least recently used records
+// are evicted when it is reached. Zero or less removes the limit.
+func (r *recordMemory) SetMaxRecords(max int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRecords = max
+	r.evict()
+}
+
+// SetTTL sets the time a record is kept without being used, zero or less keeps the records forever.
+func (r *recordMemory) SetTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ttl = ttl
+	r.evict()
+}
+
+// Reset removes all records.
+func (r *recordMemory) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = make(map[uint64]*recordUnity)
+	r.usage.Init()
+}
+
+// Stats returns the number of records held and the number of evicted records.
+func (r *recordMemory) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Stats{Records: len(r.records), Evictions: r.evictions}
+}
+
 func (r *recordMemory) RemoveRecord(id uint64) {
 	if r.GetRecord(id) == nil {
 		slog.Debug("Trying to remove non-existing record")
@@ -80,17 +194,86 @@ func (r *recordMemory) GetRecord(id uint64) IRecordUnity {
 func (r *recordMemory) readRecord(id uint64) *recordUnity {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.records[id]
+	return r.useRecord(id)
 }
 
 func (r *recordMemory) writeRecord(id uint64, req *recordUnity) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.records[id] = req
+	r.addRecord(id, req)
 }
 
 func (r *recordMemory) deleteRecord(id uint64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.removeRecord(id)
+}
+
+// useRecord returns the record of the id and marks it as the most recently used,
+// an expired record is evicted. The lock must be held.
+func (r *recordMemory) useRecord(id uint64) *recordUnity {
+	record := r.records[id]
+	if record == nil {
+		return nil
+	}
+
+	now := r.now()
+	if r.expired(record, now) {
+		r.removeRecord(id)
+		r.evictions++
+		return nil
+	}
+
+	r.touch(id, record, now)
+	return record
+}
+
+// addRecord adds the record as the most recently used and evicts the records
+// over the limits. The lock must be held.
+func (r *recordMemory) addRecord(id uint64, record *recordUnity) {
+	r.removeRecord(id)
+	r.records[id] = record
+	r.touch(id, record, r.now())
+	r.evict()
+}
+
+func (r *recordMemory) removeRecord(id uint64) {
+	record := r.records[id]
+	if record == nil {
+		return
+	}
+
+	if record.elem != nil {
+		r.usage.Remove(record.elem)
+		record.elem = nil
+	}
 	delete(r.records, id)
 }
+
+func (r *recordMemory) touch(id uint64, record *recordUnity, now time.Time) {
+	record.usedAt = now
+	if record.elem == nil {
+		record.elem = r.usage.PushFront(id)
+		return
+	}
+	r.usage.MoveToFront(record.elem)
+}
+
+func (r *recordMemory) expired(record *recordUnity, now time.Time) bool {
+	return r.ttl > 0 && record.elem != nil && now.Sub(record.usedAt) >= r.ttl
+}
+
+// evict removes the least recently used records which are expired or over the max number of records.
+func (r *recordMemory) evict() {
+	now := r.now()
+
+	for back := r.usage.Back(); back != nil; back = r.usage.Back() {
+		id := back.Value.(uint64)
+		if !r.expired(r.records[id], now) && (r.maxRecords <= 0 || len(r.records) <= r.maxRecords) {
+			return
+		}
+
+		r.removeRecord(id)
+		r.evictions++
+	}
+}
@@ -104,6 +104,159 @@ func TestAddRecord(t *testing.T) {
 	})
 }
 
+func TestLoadOrAddRecord(t *testing.T) {
+	t.Run("should add the record when it does not exist", func(t *testing.T) {
+		r := NewRecordMemory()
+
+		rec, added := r.LoadOrAddRecord(1, "test")
+		if !added || rec == nil {
+			t.Fatal("expected the record to be added")
+		}
+		if rec.GetMsgHash() != GenHash("test") {
+			t.Errorf("expected message hash to be %q, but got %q", GenHash("test"), rec.GetMsgHash())
+		}
+	})
+
+	t.Run("should return the existing record", func(t *testing.T) {
+		r := NewRecordMemory()
+
+		first, _ := r.LoadOrAddRecord(1, "test")
+		rec, added := r.LoadOrAddRecord(1, "other")
+		if added {
+			t.Error("expected the record not to be added")
+		}
+		if rec != first {
+			t.Error("expected the existing record")
+		}
+	})
+}
+
+func TestRecordUnityCount(t *testing.T) {
+	r := &recordUnity{}
+
+	if r.IncCount() != 1 || r.IncCount() != 2 {
+		t.Error("expected IncCount() to return the new count")
+	}
+	if r.GetCount() != 2 {
+		t.Errorf("expected the count to be 2, but got %d", r.GetCount())
+	}
+}
+
+func TestRecordUnityLastEmitted(t *testing.T) {
+	r := &recordUnity{}
+	now := time.Now()
+
+	if !r.GetLastEmitted().IsZero() {
+		t.Error("expected the last emitted time to be zero")
+	}
+
+	if !r.CompareAndSwapLastEmitted(time.Time{}, now) {
+		t.Error("expected the last emitted time to be set")
+	}
+	if !r.GetLastEmitted().Equal(now) {
+		t.Errorf("expected the last emitted time to be %v, but got %v", now, r.GetLastEmitted())
+	}
+
+	if r.CompareAndSwapLastEmitted(time.Time{}, now.Add(time.Second)) {
+		t.Error("expected the last emitted time not to be set when the old time is not the current")
+	}
+}
+
+func TestMaxRecords(t *testing.T) {
+	t.Run("should evict the least recently used records", func(t *testing.T) {
+		r := NewRecordMemory()
+		r.SetMaxRecords(2)
+
+		r.AddRecord(1, "one")
+		r.AddRecord(2, "two")
+		r.GetRecord(1)
+		r.AddRecord(3, "three")
+
+		if r.GetRecord(2) != nil {
+			t.Error("expected the least recently used record to be evicted")
+		}
+		if r.GetRecord(1) == nil || r.GetRecord(3) == nil {
+			t.Error("expected the recently used records to be kept")
+		}
+
+		if stats := r.Stats(); stats != (Stats{Records: 2, Evictions: 1}) {
+			t.Errorf("expected the stats to be %+v, but got %+v", Stats{Records: 2, Evictions: 1}, stats)
+		}
+	})
+
+	t.Run("should evict the records over a new max", func(t *testing.T) {
+		r := NewRecordMemory()
+
+		for id := range uint64(5) {
+			r.LoadOrAddRecord(id, "")
+		}
+		r.SetMaxRecords(3)
+
+		if stats := r.Stats(); stats != (Stats{Records: 3, Evictions: 2}) {
+			t.Errorf("expected the stats to be %+v, but got %+v", Stats{Records: 3, Evictions: 2}, stats)
+		}
+		if r.GetRecord(0) != nil || r.GetRecord(1) != nil {
+			t.Error("expected the oldest records to be evicted")
+		}
+	})
+}
+
+func TestTTL(t *testing.T) {
+	t.Run("should evict the records not used in the ttl", func(t *testing.T) {
+		_r := NewRecordMemory()
+		r, ok := _r.(*recordMemory)
+		if !ok {
+			t.Fatal("NewRecordMemory did not returned a instace of record memory")
+		}
+
+		now := time.Now()
+		r.now = func() time.Time { return now }
+		r.SetTTL(time.Minute)
+
+		r.AddRecord(1, "one")
+		r.AddRecord(2, "two")
+
+		now = now.Add(30 * time.Second)
+		r.GetRecord(1)
+
+		now = now.Add(30 * time.Second)
+		if r.GetRecord(2) != nil {
+			t.Error("expected the record not used in the ttl to be evicted")
+		}
+		if r.GetRecord(1) == nil {
+			t.Error("expected the record used in the ttl to be kept")
+		}
+
+		now = now.Add(2 * time.Minute)
+		r.AddRecord(3, "three")
+
+		if stats := r.Stats(); stats != (Stats{Records: 1, Evictions: 2}) {
+			t.Errorf("expected the stats to be %+v, but got %+v", Stats{Records: 1, Evictions: 2}, stats)
+		}
+	})
+}
+
+func TestReset(t *testing.T) {
+	t.Run("should remove all records", func(t *testing.T) {
+		r := NewRecordMemory()
+		r.AddRecord(1, "one")
+		r.AddRecord(2, "two")
+
+		r.Reset()
+
+		if r.GetRecord(1) != nil || r.GetRecord(2) != nil {
+			t.Error("expected no records")
+		}
+		if stats := r.Stats(); stats.Records != 0 {
+			t.Errorf("expected no records, but got %d", stats.Records)
+		}
+
+		if err := r.AddRecord(1, "one"); err != nil {
+			t.Errorf("expected the record to be added again, but got %v", err)
+		}
+	})
+}
+
 func TestRemoveRecord(t *testing.T) {
 	t.Run("Simple Remove", func(t *testing.T) {
 		id := uint64(1)
@@ -20,13 +20,26 @@ type coreService struct {
 	logEngine       logengine.ILogger
 	rotationService IRotationService
 
+	exitFunc func(code int)
+
+	rotationWriterOptions logengine.WriterOptions
+
+	// retiredRotationStats are the metrics of the rotation engines replaced
+	// by CreateRotationService, so the counters do not reset
+	retiredRotationStats rotationengine.Stats
+
 	serviceStatusLock sync.Mutex
+	exitFuncLock      sync.Mutex
 }
 
 type ICoreService interface {
 	IService
 	LogEngine() logengine.ILogger
 	CreateRotationService(folder string, maxFolderSize uint, rotation rotationengine.PeriodicRotation)
+	SetExitFunc(fn func(code int))
+	Exit(code int)
+	RotationStats() rotationengine.Stats
+	SetRotationWriterOptions(options logengine.WriterOptions)
 }
 
 func NewCoreService() ICoreService {
@@ -34,6 +47,7 @@ func NewCoreService() ICoreService {
 	cs.ctx, cs.cancel = context.WithCancel(context.Background())
 	cs.logEngine = logengine.NewLogger()
 	cs.rotationService = nil // will be set if rotation is enabled by the user
+	cs.exitFunc = os.Exit
 	return cs
 }
 
@@ -45,12 +59,58 @@ func (c *coreService) CreateRotationService(folder string, maxFolderSize uint, r
 	if c.rotationService != nil {
 		c.LogEngine().Writer().DeleteWriter(c.rotationService.RotationEngine())
 		c.rotationService.Stop()
+
+		stats := c.rotationService.RotationEngine().Stats()
+		c.retiredRotationStats.Rotations += stats.Rotations
+		c.retiredRotationStats.DeletedFiles += stats.DeletedFiles
 	}
 
 	c.rotationService = NewRotationService(folder, maxFolderSize, rotation)
+	c.LogEngine().Writer().SetWriterOptions(c.rotationService.RotationEngine(), c.rotationWriterOptions)
 	c.LogEngine().Writer().AddWriter(c.rotationService.RotationEngine())
 }
 
+// SetRotationWriterOptions sets the levels and the format of the rotation writer,
+// they are kept for the rotation created later
+func (c *coreService) SetRotationWriterOptions(options logengine.WriterOptions) {
+	c.rotationWriterOptions = options
+	if c.rotationService != nil {
+		c.LogEngine().Writer().SetWriterOptions(c.rotationService.RotationEngine(), options)
+	}
+}
+
+// RotationStats returns the metrics of the rotation engines, including the ones replaced
+// by CreateRotationService, or zero when the rotation is not enabled
+func (c *coreService) RotationStats() rotationengine.Stats {
+	stats := c.retiredRotationStats
+	if c.rotationService != nil {
+		current := c.rotationService.RotationEngine().Stats()
+		stats.Rotations += current.Rotations
+		stats.DeletedFiles += current.DeletedFiles
+	}
+	return stats
+}
+
+// SetExitFunc sets the function called by Exit, a nil function restores os.Exit
+func (c *coreService) SetExitFunc(fn func(code int)) {
+	c.exitFuncLock.Lock()
+	defer c.exitFuncLock.Unlock()
+
+	if fn == nil {
+		fn = os.Exit
+	}
+	c.exitFunc = fn
+}
+
+// Exit terminates the program with the given status code using the exit function
+func (c *coreService) Exit(code int) {
+	c.exitFuncLock.Lock()
+	exit := c.exitFunc
+	c.exitFuncLock.Unlock()
+
+	exit(code)
+}
+
 // Start starts the logger service, it blocks until the service is stopped
 func (c *coreService) Start(startSync *sync.WaitGroup) {
 	defer func() {
@@ -85,6 +145,7 @@ func (c *coreService) Stop() {
 	c.cancel()
 	c.serviceWg.Wait()
 	c.logEngine.FlushReports()
+	c.logEngine.Writer().Close()
 
 	if c.rotationService != nil {
 		c.rotationService.Stop()
@@ -113,6 +113,74 @@ func TestCreateRotationService(t *testing.T) {
 			t.Error("expected remove all file and the directory")
 		}
 	})
+
+	t.Run("should keep the writer options for the rotation created later", func(t *testing.T) {
+		cs := NewCoreService()
+		cs.SetRotationWriterOptions(logengine.WriterOptions{
+			Levels: func(level logengine.Level) bool { return level >= logengine.Error },
+		})
+		cs.CreateRotationService(folderName, 10, rotationengine.Daily)
+		defer os.RemoveAll(folderName)
+
+		writer := cs.LogEngine().Writer()
+
+		cs.LogEngine().Report(logengine.ReportType{Level: logengine.Info, Msg: "info"})
+		if stats := writer.Stats(); len(stats) != 1 || stats[0].Bytes != 0 {
+			t.Fatalf("expected the info entry to be filtered, but got %v", stats)
+		}
+
+		cs.LogEngine().Report(logengine.ReportType{Level: logengine.Error, Msg: "error"})
+		if stats := writer.Stats(); stats[0].Bytes == 0 {
+			t.Errorf("expected the error entry written on the rotation file, but got %v", stats)
+		}
+	})
+
+	t.Run("should keep the rotation metrics when the rotation is replaced", func(t *testing.T) {
+		cs := NewCoreService()
+		_cs, ok := cs.(*coreService)
+		if !ok {
+			t.Fatal("expected a instance of core service to implement ICoreService")
+		}
+		defer os.RemoveAll(folderName)
+
+		for range 2 {
+			cs.CreateRotationService(folderName, 10, rotationengine.Daily)
+
+			_r, ok := _cs.rotationService.(*rotationService)
+			if !ok {
+				t.Fatal("expected a instance of rotation service")
+			}
+
+			// the engine reports a rotation and a deleted file
+			writer := cs.LogEngine().Writer()
+			writer.DeleteWriter(_r.rotationEngine)
+			_r.rotationEngine = &statsRotationEngine{
+				IRotationEngine: _r.rotationEngine,
+				stats:           rotationengine.Stats{Rotations: 1, DeletedFiles: 1},
+			}
+			writer.AddWriter(_r.rotationEngine)
+		}
+
+		expected := rotationengine.Stats{Rotations: 2, DeletedFiles: 2}
+		if stats := cs.RotationStats(); stats != expected {
+			t.Errorf("expected the metrics of both rotations %+v, but got %+v", expected, stats)
+		}
+
+		cs.CreateRotationService(folderName, 10, rotationengine.Daily)
+		if stats := cs.RotationStats(); stats != expected {
+			t.Errorf("expected the metrics %+v after the rotation is replaced, but got %+v", expected, stats)
+		}
+	})
+}
+
+// statsRotationEngine is a rotation engine with the given metrics.
+type statsRotationEngine struct {
+	rotationengine.IRotationEngine
+	stats rotationengine.Stats
+}
+
+func (s *statsRotationEngine) Stats() rotationengine.Stats {
+	return s.stats
 }
 
 type mockBufferWriter struct {
@@ -153,16 +221,45 @@ func (m *mockBufferWriter) Len() int {
 	return m.buf.Len()
 }
 
+func TestExit(t *testing.T) {
+	t.Run("should call the exit function with the code", func(t *testing.T) {
+		cs := NewCoreService()
+
+		code := -1
+		cs.SetExitFunc(func(c int) { code = c })
+		cs.Exit(1)
+
+		if code != 1 {
+			t.Errorf("expected the exit code to be 1, but got %d", code)
+		}
+	})
+
+	t.Run("should restore os.Exit when the exit function is nil", func(t *testing.T) {
+		cs := NewCoreService()
+		_cs, ok := cs.(*coreService)
+		if !ok {
+			t.Fatal("expected a instance of core service to implement ICoreService")
+		}
+
+		cs.SetExitFunc(func(int) {})
+		cs.SetExitFunc(nil)
+
+		if reflect.ValueOf(_cs.exitFunc).Pointer() != reflect.ValueOf(os.Exit).Pointer() {
+			t.Error("expected the exit function to be os.Exit")
+		}
+	})
+}
+
 func TestStart_Core(t *testing.T) {
 	r := logengine.ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      logengine.Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
 	}
 
-	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, r.Time, r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
+	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":%d}
+`, r.Time.Format(time.RFC3339), r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
 
 	t.Run("should receive the message on buffer", func(t *testing.T) {
 		cs := NewCoreService()
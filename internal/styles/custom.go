@@ -7,7 +7,10 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
 )
 
 // customWriter type of customs writers
@@ -36,7 +39,13 @@ const (
 )
 
 func (c *customWriter) Write(p []byte) (int, error) {
-	log, err := processLogLine(p)
+	return c.WriteTimeFormat(p, logengine.TimeFormat{})
+}
+
+// WriteTimeFormat writes the entry whose time is written with the format,
+// the logger which the writer is added to writes its entries with its time format.
+func (c *customWriter) WriteTimeFormat(p []byte, format logengine.TimeFormat) (int, error) {
+	log, err := processLogLine(p, format)
 	if err != nil {
 		return 0, fmt.Errorf("failed to process log line: %w", err)
 	}
@@ -54,20 +63,45 @@ func CustomOutput(output io.Writer) io.Writer {
 	return instance
 }
 
+// prettyFormatter formats the JSON entries as the colored lines of CustomOutput,
+// the time of the entries is read and shown with its format.
+type prettyFormatter struct {
+	timeFormat logengine.TimeFormat
+}
+
+func (p *prettyFormatter) Format(entry []byte) ([]byte, error) {
+	return processLogLine(entry, p.timeFormat)
+}
+
+// ForTimeFormat returns the formatter for the time format of the logger which the formatter is set to.
+func (p *prettyFormatter) ForTimeFormat(format logengine.TimeFormat) logengine.Formatter {
+	return PrettyFormatter(format)
+}
+
+// prettyFormatters keeps a formatter per time format.
+var prettyFormatters sync.Map
+
+// PrettyFormatter returns the formatter of the colored lines for terminals, for the entries
+// of a logger with the time format. Every call with the same format returns the same formatter,
+// so the entries are formatted once for all its writers.
+func PrettyFormatter(format logengine.TimeFormat) logengine.Formatter {
+	f, _ := prettyFormatters.LoadOrStore(format, &prettyFormatter{timeFormat: format})
+	return f.(*prettyFormatter)
+}
+
 var logEntryKeyDefault = []string{"time", "level", "msg", "file", "package", "function", "line"}
 
-func processLogLine(line []byte) ([]byte, error) {
+func processLogLine(line []byte, format logengine.TimeFormat) ([]byte, error) {
 	if line == nil {
 		return nil, ErrNilLine
 	}
 
-	var entry logEntry
-	err := json.Unmarshal(line, &entry)
+	entry, err := decodeLogEntry(line)
 	if err != nil {
 		return nil, err
 	}
 
-	timestamp := formatTimestamp(entry["time"])
+	timestamp := formatTimestamp(entry["time"], format)
 	functionName := formatFunctionName(entry["function"])
 	levelColor := getLevelColor(entry["level"])
 	staticField := formatStaticField(entry)
@@ -90,12 +124,50 @@ func processLogLine(line []byte) ([]byte, error) {
 	return []byte(formatLine), nil
 }
 
-func formatTimestamp(timeStr string) string {
-	t, err := time.Parse(time.RFC3339Nano, timeStr)
+// decodeLogEntry decodes the log line keeping every value as text,
+// strings are unquoted and the other JSON values are kept as they are written.
+func decodeLogEntry(line []byte) (logEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, err
+	}
+
+	entry := make(logEntry, len(raw))
+	for k, v := range raw {
+		var str string
+		if len(v) > 0 && v[0] == '"' && json.Unmarshal(v, &str) == nil {
+			entry[k] = str
+			continue
+		}
+		entry[k] = string(v)
+	}
+
+	return entry, nil
+}
+
+// formatTimestamp shows the time written with the format with its precision and zone,
+// the time is shown as it is written when it cannot be read.
+func formatTimestamp(timeStr string, format logengine.TimeFormat) string {
+	t, err := format.Parse(timeStr)
 	if err != nil {
 		return timeStr
 	}
-	return t.Format(time.RFC3339)
+	return t.Format(timestampLayout(format))
+}
+
+// timestampLayout returns the layout of the time of the colored lines,
+// the Unix times are shown as RFC3339 with their precision.
+func timestampLayout(format logengine.TimeFormat) string {
+	switch format.Layout {
+	case "", logengine.UnixSeconds:
+		return time.RFC3339
+	case logengine.UnixMillis:
+		return "2006-01-02T15:04:05.000Z07:00"
+	case logengine.UnixNanos:
+		return time.RFC3339Nano
+	default:
+		return format.Layout
+	}
 }
 
 func formatFunctionName(function string) string {
@@ -124,8 +196,16 @@ func getLevelColor(level string) string {
 		return reset
 	}
 }
+
+// formatStaticField formats the fields which are not default fields,
+// the default fields may be missing, as the time of a slog record with a zero time.
 func formatStaticField(entry map[string]string) string {
-	numStaticFields := len(entry) - len(logEntryKeyDefault)
+	numStaticFields := 0
+	for k := range entry {
+		if !slices.Contains(logEntryKeyDefault, k) {
+			numStaticFields++
+		}
+	}
 	if numStaticFields == 0 {
 		return ""
 	}
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,17 +16,17 @@ import (
 
 func TestWrite(t *testing.T) {
 	r := logengine.ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      logengine.Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
 	}
 
 	reportLog := fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, r.Time, r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
+`, r.Time.Format(time.RFC3339), r.Level, r.Msg, r.CallerInfo.File, r.CallerInfo.Package, r.CallerInfo.Function, r.CallerInfo.Line)
 
 	t.Run("should write slice of byte on stdout", func(t *testing.T) {
-		processedLog, err := processLogLine([]byte(reportLog))
+		processedLog, err := processLogLine([]byte(reportLog), logengine.TimeFormat{})
 		if err != nil {
 			t.Errorf("expected no error, but got %q", err)
 		}
@@ -42,7 +43,7 @@ func TestWrite(t *testing.T) {
 
 func TestProcessLogline(t *testing.T) {
 	t.Run("should return nil when line is nil", func(t *testing.T) {
-		format, err := processLogLine(nil)
+		format, err := processLogLine(nil, logengine.TimeFormat{})
 		if err == nil {
 			t.Errorf("expected an error when line is nil, but got nil")
 		}
@@ -55,7 +56,7 @@ func TestProcessLogline(t *testing.T) {
 	t.Run("should return nil when could not decode the json", func(t *testing.T) {
 		line := []byte(`"key":"value"`)
 
-		log, err := processLogLine(line)
+		log, err := processLogLine(line, logengine.TimeFormat{})
 		if err == nil {
 			t.Errorf("expected an error when decoding json, but got nil")
 		}
@@ -73,7 +74,7 @@ func TestProcessLogline(t *testing.T) {
 		}{
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Debug,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -81,7 +82,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Info,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -89,7 +90,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Warn,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -97,7 +98,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Error,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -105,7 +106,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Fatal,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -113,7 +114,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Panic,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -121,7 +122,7 @@ func TestProcessLogline(t *testing.T) {
 			},
 			{
 				report: logengine.ReportType{
-					Time:       time.Now().Format(time.RFC3339),
+					Time:       time.Now(),
 					Level:      logengine.Trace,
 					Msg:        "Hello World",
 					CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -131,7 +132,7 @@ func TestProcessLogline(t *testing.T) {
 
 		for _, tt := range testCase {
 			t.Run(tt.report.Level.String(), func(t *testing.T) {
-				timestamp := formatTimestamp(tt.report.Time)
+				timestamp := formatTimestamp(tt.report.Time.Format(time.RFC3339), logengine.TimeFormat{})
 				levelColor := getLevelColor(tt.report.Level.String())
 				functionName := formatFunctionName(tt.report.CallerInfo.Function)
 
@@ -149,9 +150,9 @@ func TestProcessLogline(t *testing.T) {
 				)
 
 				tt.reportLog = fmt.Sprintf(`{"time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, tt.report.Time, tt.report.Level, tt.report.Msg, tt.report.CallerInfo.File, tt.report.CallerInfo.Package, tt.report.CallerInfo.Function, tt.report.CallerInfo.Line)
+`, tt.report.Time.Format(time.RFC3339), tt.report.Level, tt.report.Msg, tt.report.CallerInfo.File, tt.report.CallerInfo.Package, tt.report.CallerInfo.Function, tt.report.CallerInfo.Line)
 
-				gotLog, err := processLogLine([]byte(tt.reportLog))
+				gotLog, err := processLogLine([]byte(tt.reportLog), logengine.TimeFormat{})
 				if err != nil {
 					t.Errorf("expected no error, but got %q", err)
 				}
@@ -165,7 +166,7 @@ func TestProcessLogline(t *testing.T) {
 
 	t.Run("should return the correct format with static fields", func(t *testing.T) {
 		report := logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
+			Time:       time.Now(),
 			Level:      logengine.Info,
 			Msg:        "Hello World",
 			CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -173,7 +174,7 @@ func TestProcessLogline(t *testing.T) {
 
 		var entry logEntry
 		reportLog := fmt.Sprintf(`{"test":"123","time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, report.Time, report.Level, report.Msg, report.CallerInfo.File, report.CallerInfo.Package, report.CallerInfo.Function, report.CallerInfo.Line)
+`, report.Time.Format(time.RFC3339), report.Level, report.Msg, report.CallerInfo.File, report.CallerInfo.Package, report.CallerInfo.Function, report.CallerInfo.Line)
 		if err := json.Unmarshal([]byte(reportLog), &entry); err != nil {
 			t.Errorf("expected no error, but got %q", err)
 		}
@@ -181,7 +182,7 @@ func TestProcessLogline(t *testing.T) {
 		staticFieldMap := map[string]string{"test": "123"}
 		maps.Copy(entry, staticFieldMap)
 
-		timestamp := formatTimestamp(report.Time)
+		timestamp := formatTimestamp(report.Time.Format(time.RFC3339), logengine.TimeFormat{})
 		levelColor := getLevelColor(report.Level.String())
 		functionName := formatFunctionName(report.CallerInfo.Function)
 		staticField := formatStaticField(entry)
@@ -200,7 +201,7 @@ func TestProcessLogline(t *testing.T) {
 			staticField,
 		)
 
-		gotLog, err := processLogLine([]byte(reportLog))
+		gotLog, err := processLogLine([]byte(reportLog), logengine.TimeFormat{})
 		if err != nil {
 			t.Errorf("expected no error, but got %q", err)
 		}
@@ -209,11 +210,65 @@ func TestProcessLogline(t *testing.T) {
 			t.Errorf("expected log to be %q, but got %q", expectFormatLog, gotLog)
 		}
 	})
+
+	t.Run("should format an entry without time", func(t *testing.T) {
+		for _, tt := range []struct {
+			line     string
+			expected string
+		}{
+			{
+				line:     `{"level":"INFO","msg":"m","file":"a.go","package":"p","function":"p.F","line":1}`,
+				expected: reset + ") \n",
+			},
+			{
+				line:     `{"id":"abc","level":"INFO","msg":"m","file":"a.go","package":"p","function":"p.F","line":1}`,
+				expected: reset + ") id:abc \n",
+			},
+		} {
+			gotLog, err := processLogLine([]byte(tt.line), logengine.TimeFormat{})
+			if err != nil {
+				t.Fatalf("expected no error, but got %q", err)
+			}
+
+			if !strings.Contains(string(gotLog), "m"+reset) || !strings.HasSuffix(string(gotLog), tt.expected) {
+				t.Errorf("expected the line to end with %q, but got %q", tt.expected, gotLog)
+			}
+		}
+	})
+}
+
+func TestDecodeLogEntry(t *testing.T) {
+	t.Run("should keep the typed values as text", func(t *testing.T) {
+		line := []byte(`{"msg":"hello","line":42,"ok":true,"nothing":null,"obj":{"a":[1,2]}}`)
+
+		entry, err := decodeLogEntry(line)
+		if err != nil {
+			t.Fatalf("expected no error, but got %q", err)
+		}
+
+		expectedEntry := logEntry{
+			"msg":     "hello",
+			"line":    "42",
+			"ok":      "true",
+			"nothing": "null",
+			"obj":     `{"a":[1,2]}`,
+		}
+
+		if !reflect.DeepEqual(entry, expectedEntry) {
+			t.Errorf("expected entry to be %v, but got %v", expectedEntry, entry)
+		}
+	})
+
+	t.Run("should return an error when could not decode the json", func(t *testing.T) {
+		if _, err := decodeLogEntry([]byte(`"key":"value"`)); err == nil {
+			t.Error("expected an error when decoding json, but got nil")
+		}
+	})
 }
 
 func BenchmarkProcessLogLine(b *testing.B) {
 	report := logengine.ReportType{
-		Time:       time.Now().Format(time.RFC3339),
+		Time:       time.Now(),
 		Level:      logengine.Info,
 		Msg:        "Hello World",
 		CallerInfo: runtimeinfo.GetCallerInfo(1),
@@ -221,7 +276,7 @@ func BenchmarkProcessLogLine(b *testing.B) {
 
 	var entry logEntry
 	reportLog := fmt.Sprintf(`{"test":"123","ionic":"health","time":"%s","level":"%s","msg":"%s","file":"%s","package":"%s","function":"%s","line":"%d"}
-`, report.Time, report.Level, report.Msg, report.CallerInfo.File, report.CallerInfo.Package, report.CallerInfo.Function, report.CallerInfo.Line)
+`, report.Time.Format(time.RFC3339), report.Level, report.Msg, report.CallerInfo.File, report.CallerInfo.Package, report.CallerInfo.Function, report.CallerInfo.Line)
 	if err := json.Unmarshal([]byte(reportLog), &entry); err != nil {
 		b.Errorf("expected no error, but got %q", err)
 	}
@@ -229,7 +284,7 @@ func BenchmarkProcessLogLine(b *testing.B) {
 	b.ResetTimer()
 
 	for range b.N {
-		_, _ = processLogLine([]byte(reportLog))
+		_, _ = processLogLine([]byte(reportLog), logengine.TimeFormat{})
 	}
 }
 
@@ -272,6 +327,25 @@ func TestFormatStaticField(t *testing.T) {
 			t.Errorf("expcted the static field to be %q, but got %q", expectedFormatStaticFields, gotFormatStaticFields)
 		}
 	})
+
+	t.Run("should return the static field of an entry without time", func(t *testing.T) {
+		expectedFormatStaticFields := "computer-id:q "
+		entry := map[string]string{
+			"level":       "DEBUG",
+			"msg":         "test 123",
+			"file":        "format.go",
+			"package":     "styles",
+			"function":    "formatStaticField",
+			"line":        "123",
+			"computer-id": "q",
+		}
+
+		gotFormatStaticFields := formatStaticField(entry)
+
+		if gotFormatStaticFields != expectedFormatStaticFields {
+			t.Errorf("expcted the static field to be %q, but got %q", expectedFormatStaticFields, gotFormatStaticFields)
+		}
+	})
 }
 
 func BenchmarkFormatStaticField(b *testing.B) {
@@ -305,7 +379,7 @@ func TestFormatTimestamp(t *testing.T) {
 			t.Errorf("expected no error to parse the time, but got %q", err)
 		}
 
-		if format := formatTimestamp(timeStr); format != expectedTimeStr.Format(time.RFC3339) {
+		if format := formatTimestamp(timeStr, logengine.TimeFormat{}); format != expectedTimeStr.Format(time.RFC3339) {
 			t.Errorf("expected time format to be %q, but got %q", expectedTimeStr.Format(time.RFC3339), format)
 		}
 	})
@@ -313,7 +387,7 @@ func TestFormatTimestamp(t *testing.T) {
 	t.Run("should return the arg timeStr", func(t *testing.T) {
 		timeStr := "123456789.ABC"
 
-		if format := formatTimestamp(timeStr); format != timeStr {
+		if format := formatTimestamp(timeStr, logengine.TimeFormat{}); format != timeStr {
 			t.Errorf("expected time format to be %q, but got %q", timeStr, format)
 		}
 	})
@@ -326,7 +400,7 @@ func BenchmarkFormatTimestamp(b *testing.B) {
 	b.ResetTimer()
 
 	for range b.N {
-		_ = formatTimestamp(timeStr)
+		_ = formatTimestamp(timeStr, logengine.TimeFormat{})
 	}
 }
 
@@ -416,3 +490,52 @@ func BenchmarkGetLevelColor(b *testing.B) {
 		_ = getLevelColor("INFO")
 	}
 }
+
+func TestPrettyFormatter(t *testing.T) {
+	t.Run("should show the Unix times with their precision", func(t *testing.T) {
+		format := logengine.TimeFormat{Layout: logengine.UnixMillis, UTC: true}
+
+		if got := formatTimestamp("1735797845123", format); got != "2025-01-02T06:04:05.123Z" {
+			t.Errorf("expected time format to be %q, but got %q", "2025-01-02T06:04:05.123Z", got)
+		}
+	})
+
+	t.Run("should show the time with the layout", func(t *testing.T) {
+		timeStr := "2025-01-02T06:04:05.123456789Z"
+
+		if got := formatTimestamp(timeStr, logengine.TimeFormat{Layout: time.RFC3339Nano}); got != timeStr {
+			t.Errorf("expected time format to be %q, but got %q", timeStr, got)
+		}
+	})
+
+	t.Run("should return the same formatter for the same format", func(t *testing.T) {
+		format := logengine.TimeFormat{Layout: logengine.UnixNanos}
+
+		if PrettyFormatter(format) != PrettyFormatter(format) {
+			t.Error("expected the same formatter for the same format")
+		}
+		if PrettyFormatter(format) == PrettyFormatter(logengine.TimeFormat{}) {
+			t.Error("expected another formatter for another format")
+		}
+	})
+
+	t.Run("should keep the format of every formatter", func(t *testing.T) {
+		line := []byte(`{"time":1735797845,"level":"INFO","msg":"m","file":"f.go","package":"p","function":"F","line":1}`)
+
+		unix, err := PrettyFormatter(logengine.TimeFormat{Layout: logengine.UnixSeconds, UTC: true}).Format(line)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if !strings.Contains(string(unix), "2025-01-02T06:04:05Z") {
+			t.Errorf("expected the Unix time to be shown as RFC3339, but got %q", unix)
+		}
+
+		rfc, err := PrettyFormatter(logengine.TimeFormat{}).Format(line)
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if !strings.Contains(string(rfc), "1735797845") {
+			t.Errorf("expected the time to be shown as written, but got %q", rfc)
+		}
+	})
+}
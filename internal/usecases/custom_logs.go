@@ -3,6 +3,8 @@ package usecases
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/memory"
 )
@@ -28,3 +30,54 @@ func LogOnce(logsMemory memory.IRecordMemory, msg string, args ...string) bool {
 
 	return false
 }
+
+// LogOnceKey reports whether the call with the key is logged,
+// only the first call with each key is logged.
+func LogOnceKey(logsMemory memory.IRecordMemory, key string) bool {
+	_, added := logsMemory.LoadOrAddRecord(recordID("key", []string{key}), "")
+	return added
+}
+
+// LogEveryN reports whether the call of the call site is logged,
+// the first call and then every n-th call are logged.
+// args identify the call site, as the file, package, function and line.
+func LogEveryN(logsMemory memory.IRecordMemory, n int, args ...string) bool {
+	rec, _ := logsMemory.LoadOrAddRecord(recordID("every_n", args), "")
+
+	count := rec.IncCount()
+	return n <= 1 || (count-1)%uint64(n) == 0
+}
+
+// LogFirstN reports whether the call of the call site is logged,
+// only the first n calls are logged.
+// args identify the call site, as the file, package, function and line.
+func LogFirstN(logsMemory memory.IRecordMemory, n int, args ...string) bool {
+	if n <= 0 {
+		return false
+	}
+
+	rec, _ := logsMemory.LoadOrAddRecord(recordID("first_n", args), "")
+
+	return rec.IncCount() <= uint64(n)
+}
+
+// LogEvery reports whether the call of the call site is logged,
+// the call is logged when no other call was logged in the last period d.
+// args identify the call site, as the file, package, function and line.
+func LogEvery(logsMemory memory.IRecordMemory, d time.Duration, now time.Time, args ...string) bool {
+	rec, _ := logsMemory.LoadOrAddRecord(recordID("every", args), "")
+
+	last := rec.GetLastEmitted()
+	if !last.IsZero() && now.Sub(last) < d {
+		return false
+	}
+
+	// only one of the concurrent calls is logged
+	return rec.CompareAndSwapLastEmitted(last, now)
+}
+
+// recordID returns the id of the record of a kind of log for the call site,
+// the kind keeps the records of different kinds of log apart.
+func recordID(kind string, args []string) uint64 {
+	return memory.GenHash(kind + ":" + strings.Join(args, ":"))
+}
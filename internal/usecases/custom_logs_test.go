@@ -2,7 +2,9 @@ package usecases
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/IonicHealthUsa/ionlog/internal/infrastructure/memory"
 )
@@ -99,3 +101,107 @@ func TestLogOnce(t *testing.T) {
 		}
 	})
 }
+
+func TestLogOnceKey(t *testing.T) {
+	t.Run("should log only the first call with the key", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		if !LogOnceKey(r, "device-1") {
+			t.Error("expected the first call to be logged")
+		}
+		if LogOnceKey(r, "device-1") {
+			t.Error("expected the second call not to be logged")
+		}
+		if !LogOnceKey(r, "device-2") {
+			t.Error("expected the first call with the other key to be logged")
+		}
+	})
+}
+
+func TestLogEveryN(t *testing.T) {
+	t.Run("should log the first call and then every n-th call", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		var logged []bool
+		for range 7 {
+			logged = append(logged, LogEveryN(r, 3, "file", "pkg", "function", "10"))
+		}
+
+		expected := []bool{true, false, false, true, false, false, true}
+		if !reflect.DeepEqual(logged, expected) {
+			t.Errorf("expected the logged calls to be %v, but got %v", expected, logged)
+		}
+	})
+
+	t.Run("should count the call sites apart", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		LogEveryN(r, 3, "file", "pkg", "function", "10")
+		if !LogEveryN(r, 3, "file", "pkg", "function", "20") {
+			t.Error("expected the first call of the other call site to be logged")
+		}
+	})
+
+	t.Run("should log every call when n is not greater than one", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		for range 3 {
+			if !LogEveryN(r, 0, "file", "pkg", "function", "10") {
+				t.Error("expected every call to be logged")
+			}
+		}
+	})
+}
+
+func TestLogFirstN(t *testing.T) {
+	t.Run("should log only the first n calls", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		var logged []bool
+		for range 4 {
+			logged = append(logged, LogFirstN(r, 2, "file", "pkg", "function", "10"))
+		}
+
+		expected := []bool{true, true, false, false}
+		if !reflect.DeepEqual(logged, expected) {
+			t.Errorf("expected the logged calls to be %v, but got %v", expected, logged)
+		}
+	})
+
+	t.Run("should not log when n is not positive", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		if LogFirstN(r, 0, "file", "pkg", "function", "10") {
+			t.Error("expected the call not to be logged")
+		}
+	})
+
+	t.Run("should not share the record with LogEveryN", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+
+		LogEveryN(r, 3, "file", "pkg", "function", "10")
+		LogEveryN(r, 3, "file", "pkg", "function", "10")
+		if !LogFirstN(r, 1, "file", "pkg", "function", "10") {
+			t.Error("expected the first call to be logged")
+		}
+	})
+}
+
+func TestLogEvery(t *testing.T) {
+	t.Run("should log once in every period", func(t *testing.T) {
+		r := memory.NewRecordMemory()
+		now := time.Now()
+
+		steps := []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second}
+
+		var logged []bool
+		for _, step := range steps {
+			logged = append(logged, LogEvery(r, time.Second, now.Add(step), "file", "pkg", "function", "10"))
+		}
+
+		expected := []bool{true, false, true, false, true}
+		if !reflect.DeepEqual(logged, expected) {
+			t.Errorf("expected the logged calls to be %v, but got %v", expected, logged)
+		}
+	})
+}
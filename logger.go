@@ -1,236 +1,323 @@
 package ionlog
 
 import (
-	"fmt"
-	"sync"
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
-	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
-	"github.com/IonicHealthUsa/ionlog/internal/service"
-	"github.com/IonicHealthUsa/ionlog/internal/usecases"
 )
 
 // Start begin the ionlog reports when it does not running
 func Start() {
-	startSync := sync.WaitGroup{}
-	startSync.Add(1)
-	go logger.Start(&startSync)
-	startSync.Wait()
+	logger.Start()
 }
 
 // Stop stop the ionlog reports and reset the logger
 func Stop() {
 	logger.Stop()
-	logger = service.NewCoreService() // Reset the logger
+	logger = New() // Reset the logger
 }
 
 // Flush flushes the reports to the output writers.
 func Flush() {
-	logger.LogEngine().FlushReports()
+	logger.Flush()
+}
+
+// Enabled reports whether the default logger writes entries of the given level.
+// It can guard the construction of expensive log arguments.
+// usage: if ionlog.Enabled(ionlog.DebugLevel) { ionlog.Debugw("state", "dump", dump()) }
+func Enabled(level Level) bool {
+	return logger.enabled(level)
+}
+
+// ResetLogOnce forgets the messages of the log once and periodic log functions,
+// so they are logged again.
+func ResetLogOnce() {
+	logger.ResetLogOnce()
+}
+
+// LogOnceStats returns the number of records held for the log once and periodic log functions
+// and the number of evicted records.
+func LogOnceStats() RecordStats {
+	return logger.LogOnceStats()
+}
+
+// QueueStats returns the metrics of the reports queue of the default logger.
+func QueueStats() ReportQueueStats {
+	return logger.QueueStats()
+}
+
+// Stats returns the metrics of the default logger pipeline.
+func Stats() Metrics {
+	return logger.Stats()
+}
+
+// MetricsHandler returns a handler which serves the metrics of the default logger in the Prometheus text format.
+// usage: http.Handle("/metrics", ionlog.MetricsHandler())
+func MetricsHandler() http.Handler {
+	return logger.MetricsHandler()
+}
+
+// With returns a child of the default logger whose entries always carry the given fields.
+// It is safe to use from many goroutines, unlike changing the static fields.
+// usage: l := ionlog.With("request_id", id); l.Info("request received")
+func With(keysAndValues ...any) *Logger {
+	return logger.With(keysAndValues...)
 }
 
 // Info logs a message with level info.
 func Info(msg string) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Info,
-			Msg:        msg,
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Info, msg, nil, nil, nil)
 }
 
 // Infof logs a message with level info.
 // Arguments are handled in the manner of fmt.Printf.
 func Infof(msg string, args ...any) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Info,
-			Msg:        fmt.Sprintf(msg, args...),
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Info, msg, args, nil, nil)
+}
+
+// Infow logs a message with level info.
+// The keys and values are added as fields of this entry only.
+func Infow(msg string, keysAndValues ...any) {
+	logger.log(logengine.Info, msg, nil, nil, keysAndValues)
+}
+
+// InfoCtx logs a message with level info.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func InfoCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	logger.log(logengine.Info, msg, nil, FieldsFromContext(ctx), keysAndValues)
 }
 
 // Error logs a message with level error.
 func Error(msg string) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Error,
-			Msg:        msg,
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Error, msg, nil, nil, nil)
 }
 
 // Errorf logs a message with level error.
 // Arguments are handled in the manner of fmt.Printf.
 func Errorf(msg string, args ...any) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Error,
-			Msg:        fmt.Sprintf(msg, args...),
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Error, msg, args, nil, nil)
+}
+
+// Errorw logs a message with level error.
+// The keys and values are added as fields of this entry only.
+func Errorw(msg string, keysAndValues ...any) {
+	logger.log(logengine.Error, msg, nil, nil, keysAndValues)
+}
+
+// ErrorE logs a message with level error, recording the error as the Err field.
+// The keys and values are added as fields of this entry only.
+// usage: ionlog.ErrorE(err, "charge failed", "invoice", invoiceID)
+func ErrorE(err error, msg string, keysAndValues ...any) {
+	logger.log(logengine.Error, msg, nil, nil, withErr(err, keysAndValues))
+}
+
+// ErrorCtx logs a message with level error.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func ErrorCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	logger.log(logengine.Error, msg, nil, FieldsFromContext(ctx), keysAndValues)
 }
 
 // Warn logs a message with level warn.
 func Warn(msg string) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Warn,
-			Msg:        msg,
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Warn, msg, nil, nil, nil)
 }
 
 // Warnf logs a message with level warn.
 // Arguments are handled in the manner of fmt.Printf.
 func Warnf(msg string, args ...any) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Warn,
-			Msg:        fmt.Sprintf(msg, args...),
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Warn, msg, args, nil, nil)
+}
+
+// Warnw logs a message with level warn.
+// The keys and values are added as fields of this entry only.
+func Warnw(msg string, keysAndValues ...any) {
+	logger.log(logengine.Warn, msg, nil, nil, keysAndValues)
+}
+
+// WarnCtx logs a message with level warn.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func WarnCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	logger.log(logengine.Warn, msg, nil, FieldsFromContext(ctx), keysAndValues)
 }
 
 // Debug logs a message with level debug.
 func Debug(msg string) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Debug,
-			Msg:        msg,
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Debug, msg, nil, nil, nil)
 }
 
 // Debugf logs a message with level debug.
 // Arguments are handled in the manner of fmt.Printf.
 func Debugf(msg string, args ...any) {
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Debug,
-			Msg:        fmt.Sprintf(msg, args...),
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Debug, msg, args, nil, nil)
+}
+
+// Debugw logs a message with level debug.
+// The keys and values are added as fields of this entry only.
+func Debugw(msg string, keysAndValues ...any) {
+	logger.log(logengine.Debug, msg, nil, nil, keysAndValues)
+}
+
+// DebugCtx logs a message with level debug.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func DebugCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	logger.log(logengine.Debug, msg, nil, FieldsFromContext(ctx), keysAndValues)
 }
 
 // Trace logs a message with level trace only when trace mode is enable.
 func Trace(msg string) {
-	if !logger.LogEngine().TraceMode() {
-		return
-	}
-	logger.LogEngine().Report(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Trace,
-			Msg:        msg,
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Trace, msg, nil, nil, nil)
 }
 
 // Tracef logs a message with level trace only when trace mode is enable.
 // Arguments are handled in the manner of fmt.Printf.
 func Tracef(msg string, args ...any) {
-	if !logger.LogEngine().TraceMode() {
-		return
-	}
-	logger.LogEngine().Report(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      logengine.Trace,
-			Msg:        fmt.Sprintf(msg, args...),
-			CallerInfo: runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth()),
-		},
-	)
+	logger.log(logengine.Trace, msg, args, nil, nil)
+}
+
+// Tracew logs a message with level trace only when trace mode is enable.
+// The keys and values are added as fields of this entry only.
+func Tracew(msg string, keysAndValues ...any) {
+	logger.log(logengine.Trace, msg, nil, nil, keysAndValues)
+}
+
+// TraceCtx logs a message with level trace only when trace mode is enable.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func TraceCtx(ctx context.Context, msg string, keysAndValues ...any) {
+	logger.log(logengine.Trace, msg, nil, FieldsFromContext(ctx), keysAndValues)
+}
+
+// Panic logs a message with level panic and then panics with the message.
+// The entry is written synchronously and the writers are synced before the panic.
+func Panic(msg string) {
+	logger.log(logengine.Panic, msg, nil, nil, nil)
+	logger.panic(msg)
+}
+
+// Panicf logs a message with level panic and then panics with the message.
+// Arguments are handled in the manner of fmt.Printf.
+func Panicf(msg string, args ...any) {
+	msg = formatMsg(msg, args)
+	logger.log(logengine.Panic, msg, nil, nil, nil)
+	logger.panic(msg)
+}
+
+// Fatal logs a message with level fatal and then exits the program with status 1.
+// The entry is written synchronously and the writers are synced before the exit.
+func Fatal(msg string) {
+	logger.log(logengine.Fatal, msg, nil, nil, nil)
+	logger.exit()
+}
+
+// Fatalf logs a message with level fatal and then exits the program with status 1.
+// Arguments are handled in the manner of fmt.Printf.
+func Fatalf(msg string, args ...any) {
+	logger.log(logengine.Fatal, msg, args, nil, nil)
+	logger.exit()
 }
 
 // LogOnceInfo logs a message with level info only once time.
 func LogOnceInfo(msg string) {
-	logOnce(logengine.Info, msg)
+	logger.logRecord(logengine.Info, msg, nil, nil, nil, logOnceCheck)
 }
 
 // LogOnceInfof logs a message with level info only once time.
 // Arguments are handled in the manner of fmt.Printf.
 func LogOnceInfof(msg string, args ...any) {
-	logOnce(logengine.Info, fmt.Sprintf(msg, args...))
+	logger.logRecord(logengine.Info, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceInfow logs a message with level info only once time.
+// The keys and values are added as fields of this entry only.
+func LogOnceInfow(msg string, keysAndValues ...any) {
+	logger.logRecord(logengine.Info, msg, nil, nil, keysAndValues, logOnceCheck)
 }
 
 // LogOnceError logs a message with level error only once time.
-// Arguments are handled in the manner of fmt.Printf.
 func LogOnceError(msg string) {
-	logOnce(logengine.Error, msg)
+	logger.logRecord(logengine.Error, msg, nil, nil, nil, logOnceCheck)
 }
 
 // LogOnceErrorf logs a message with level error only once time.
 // Arguments are handled in the manner of fmt.Printf.
 func LogOnceErrorf(msg string, args ...any) {
-	logOnce(logengine.Error, fmt.Sprintf(msg, args...))
+	logger.logRecord(logengine.Error, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceErrorw logs a message with level error only once time.
+// The keys and values are added as fields of this entry only.
+func LogOnceErrorw(msg string, keysAndValues ...any) {
+	logger.logRecord(logengine.Error, msg, nil, nil, keysAndValues, logOnceCheck)
 }
 
 // LogOnceWarn logs a message with level warn only once time.
-// Arguments are handled in the manner of fmt.Printf.
 func LogOnceWarn(msg string) {
-	logOnce(logengine.Warn, msg)
+	logger.logRecord(logengine.Warn, msg, nil, nil, nil, logOnceCheck)
 }
 
 // LogOnceWarnf logs a message with level warn only once time.
 // Arguments are handled in the manner of fmt.Printf.
 func LogOnceWarnf(msg string, args ...any) {
-	logOnce(logengine.Warn, fmt.Sprintf(msg, args...))
+	logger.logRecord(logengine.Warn, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceWarnw logs a message with level warn only once time.
+// The keys and values are added as fields of this entry only.
+func LogOnceWarnw(msg string, keysAndValues ...any) {
+	logger.logRecord(logengine.Warn, msg, nil, nil, keysAndValues, logOnceCheck)
 }
 
 // LogOnceDebug logs a message with level debug only once time.
-// Arguments are handled in the manner of fmt.Printf.
 func LogOnceDebug(msg string) {
-	logOnce(logengine.Debug, msg)
+	logger.logRecord(logengine.Debug, msg, nil, nil, nil, logOnceCheck)
 }
 
 // LogOnceDebugf logs a message with level debug only once time.
 // Arguments are handled in the manner of fmt.Printf.
 func LogOnceDebugf(msg string, args ...any) {
-	logOnce(logengine.Debug, fmt.Sprintf(msg, args...))
-}
-
-// logOnce send the information about the function
-// which called the log level to report queue asynchronously.
-func logOnce(level logengine.Level, recordMsg string) {
-	callerInfo := runtimeinfo.GetCallerInfo(logger.LogEngine().GetCallerStackDepth() + 1)
-
-	proceed := usecases.LogOnce(
-		logger.LogEngine().Memory(),
-		recordMsg,
-		callerInfo.File,
-		callerInfo.Package,
-		callerInfo.Function,
-	)
-
-	if !proceed {
-		return
-	}
-
-	logger.LogEngine().AsyncReport(
-		logengine.ReportType{
-			Time:       time.Now().Format(time.RFC3339),
-			Level:      level,
-			Msg:        recordMsg,
-			CallerInfo: callerInfo,
-		},
-	)
+	logger.logRecord(logengine.Debug, msg, args, nil, nil, logOnceCheck)
+}
+
+// LogOnceDebugw logs a message with level debug only once time.
+// The keys and values are added as fields of this entry only.
+func LogOnceDebugw(msg string, keysAndValues ...any) {
+	logger.logRecord(logengine.Debug, msg, nil, nil, keysAndValues, logOnceCheck)
+}
+
+// LogOnceKey logs a message with the level only on the first call with the key,
+// in place of the call site, it suits the messages logged once per device or user.
+// The keys and values are added as fields of this entry only.
+// usage: ionlog.LogOnceKey("offline:"+deviceID, ionlog.WarnLevel, "Device is offline")
+func LogOnceKey(key string, level Level, msg string, keysAndValues ...any) {
+	logger.logRecord(level, msg, nil, nil, keysAndValues, onceKeyCheck(key))
+}
+
+// LogOnceKeyCtx logs a message with the level only on the first call with the key
+// in the log once scope of ctx, created by ContextWithLogOnceScope.
+// Without a scope, it is the same as LogOnceKey.
+// The fields stored in ctx and the keys and values are added as fields of this entry only.
+func LogOnceKeyCtx(ctx context.Context, key string, level Level, msg string, keysAndValues ...any) {
+	logger.logRecord(level, msg, nil, FieldsFromContext(ctx), keysAndValues, scopedOnceKeyCheck(ctx, key))
+}
+
+// LogEveryN logs a message with the level on the first call of the call site
+// and then on every n-th call, it suits the status messages of polling loops.
+// The keys and values are added as fields of this entry only.
+// usage: ionlog.LogEveryN(ionlog.InfoLevel, 100, "Polling", "pending", pending)
+func LogEveryN(level Level, n int, msg string, keysAndValues ...any) {
+	logger.logRecord(level, msg, nil, nil, keysAndValues, everyNCheck(n))
+}
+
+// LogFirstN logs a message with the level only on the first n calls of the call site.
+// The keys and values are added as fields of this entry only.
+func LogFirstN(level Level, n int, msg string, keysAndValues ...any) {
+	logger.logRecord(level, msg, nil, nil, keysAndValues, firstNCheck(n))
+}
+
+// LogEvery logs a message with the level at most once in every period d for the call site.
+// The keys and values are added as fields of this entry only.
+// usage: ionlog.LogEvery(ionlog.InfoLevel, time.Minute, "Waiting for the device", "device", id)
+func LogEvery(level Level, d time.Duration, msg string, keysAndValues ...any) {
+	logger.logRecord(level, msg, nil, nil, keysAndValues, everyCheck(d))
 }
@@ -2,21 +2,23 @@ package ionlog
 
 import (
 	"io"
+	"slices"
+	"time"
 
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
 	"github.com/IonicHealthUsa/ionlog/internal/core/rotationengine"
 	"github.com/IonicHealthUsa/ionlog/internal/service"
 )
 
 type customAttrs func(i service.ICoreService)
 
+// WriterOption sets the levels or the format of a writer.
+type WriterOption func(o *logengine.WriterOptions)
+
 // SetAttributes sets the log SetAttributes
 // fns is a variadic parameter that accepts customAttrs
 func SetAttributes(fns ...customAttrs) {
-	Flush()
-
-	for _, fn := range fns {
-		fn(logger)
-	}
+	logger.SetAttributes(fns...)
 }
 
 // WithWriters sets the write targets for the logger,
@@ -27,6 +29,97 @@ func WithWriters(w ...io.Writer) customAttrs {
 	}
 }
 
+// WithWriter adds the write target with its levels and format,
+// the writer keeps its options until it is deleted.
+// usage: WithWriter(os.Stdout, ionlog.WriterMinLevel(ionlog.InfoLevel), ionlog.WriterFormatter(ionlog.PrettyFormat))
+func WithWriter(w io.Writer, opts ...WriterOption) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Writer().SetWriterOptions(w, writerOptions(opts))
+		i.LogEngine().Writer().AddWriter(w)
+	}
+}
+
+// WithRotationWriter sets the levels and the format of the log rotation file,
+// the file gets every level as JSON by default.
+// usage: WithRotationWriter(ionlog.WriterMinLevel(ionlog.DebugLevel))
+func WithRotationWriter(opts ...WriterOption) customAttrs {
+	return func(i service.ICoreService) {
+		i.SetRotationWriterOptions(writerOptions(opts))
+	}
+}
+
+// WriterMinLevel writes the entries of the level and above.
+func WriterMinLevel(level Level) WriterOption {
+	return func(o *logengine.WriterOptions) {
+		o.Levels = func(l Level) bool { return l >= level }
+	}
+}
+
+// WriterLevels writes only the entries of the levels.
+// usage: WriterLevels(ionlog.ErrorLevel)
+func WriterLevels(levels ...Level) WriterOption {
+	levels = slices.Clone(levels)
+	return func(o *logengine.WriterOptions) {
+		o.Levels = func(l Level) bool { return slices.Contains(levels, l) }
+	}
+}
+
+// WriterFormatter formats the entries of the writer, the entries are written as JSON by default.
+// An entry is formatted once for all the writers with the same formatter.
+func WriterFormatter(formatter Formatter) WriterOption {
+	return func(o *logengine.WriterOptions) {
+		o.Formatter = formatter
+	}
+}
+
+// WriterBuffer writes the entries of the writer from its own goroutine through a buffer of the size,
+// so a slow writer does not stall the others. The policy is what is done with the entries
+// when the buffer is full, SpillToDisk blocks as Block. Only the caller is held by a full buffer,
+// after the entry is written to the writers without buffer. Flush and Stop wait for the buffered entries.
+// usage: WriterBuffer(1000, ionlog.DropOldest)
+func WriterBuffer(size int, policy BackpressurePolicy) WriterOption {
+	return func(o *logengine.WriterOptions) {
+		o.Buffer = size
+		o.Policy = policy
+	}
+}
+
+func writerOptions(opts []WriterOption) logengine.WriterOptions {
+	var options logengine.WriterOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithWriterRetry retries the writes failed by transient errors, as timeouts and EAGAIN,
+// with a backoff doubled after every attempt. The writers are not retried by default.
+// A writer without WriterBuffer is retried from the caller, so only the writers after it wait.
+// usage: WithWriterRetry(ionlog.RetryPolicy{Attempts: 3, Backoff: 10 * time.Millisecond, MaxBackoff: time.Second})
+func WithWriterRetry(retry RetryPolicy) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Writer().SetRetryPolicy(retry)
+	}
+}
+
+// WithWriterCircuitBreaker disables a writer after consecutive failed writes,
+// and probes it again after the cooldown. The writers are not disabled by default.
+// usage: WithWriterCircuitBreaker(ionlog.CircuitBreaker{Failures: 5, Cooldown: 30 * time.Second})
+func WithWriterCircuitBreaker(breaker CircuitBreaker) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Writer().SetCircuitBreaker(breaker)
+	}
+}
+
+// WithWriterErrorHandler sets the function called with the writer and the error of every failed write,
+// in place of printing the error to stderr. The handler may log, but a failing writer can loop on its own errors.
+// usage: WithWriterErrorHandler(func(w io.Writer, err error) { alert(err) })
+func WithWriterErrorHandler(handler func(w io.Writer, err error)) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Writer().SetErrorHandler(handler)
+	}
+}
+
 // WithoutWriters deletes the write targets for the logger.
 func WithoutWriters(w ...io.Writer) customAttrs {
 	return func(i service.ICoreService) {
@@ -71,6 +164,25 @@ func WithQueueSize(size uint) customAttrs {
 	}
 }
 
+// WithBackpressurePolicy sets what is done with the entries when the reports queue is full,
+// for all levels without their own policy. The default is BlockTimeout,
+// which blocks the caller for up to one second and then drops the entry.
+// usage: WithBackpressurePolicy(ionlog.DropOldest)
+func WithBackpressurePolicy(policy BackpressurePolicy) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetBackpressurePolicy(policy)
+	}
+}
+
+// WithLevelBackpressurePolicy sets the backpressure policy of the level,
+// in place of the policy set by WithBackpressurePolicy.
+// usage: WithLevelBackpressurePolicy(ionlog.ErrorLevel, ionlog.Block)
+func WithLevelBackpressurePolicy(level Level, policy BackpressurePolicy) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetLevelBackpressurePolicy(level, policy)
+	}
+}
+
 // WithTraceMode enables trace log mode.
 // For default, the trace mode is disable,
 // to enable is need pass a true boolean.
@@ -80,6 +192,128 @@ func WithTraceMode(mode bool) customAttrs {
 	}
 }
 
+// WithTimeFormat sets how the time of the entries is written, it is time.RFC3339 in the local time by default.
+// The colored lines of CustomOutput and PrettyFormat read and show the time with the same format.
+// usage: WithTimeFormat(ionlog.TimeFormat{Layout: time.RFC3339Nano, UTC: true})
+// usage: WithTimeFormat(ionlog.TimeFormat{Layout: ionlog.UnixMillis})
+func WithTimeFormat(format TimeFormat) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetTimeFormat(format)
+	}
+}
+
+// WithMinLevel sets the lowest level written by the logger,
+// the entries below it are discarded before the caller information
+// and the message are built, so they cost close to nothing.
+// For default, every level is written, with trace depending on the trace mode.
+func WithMinLevel(level Level) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetMinLevel(level)
+	}
+}
+
+// WithLevelRules adds or replaces level rules, which set the min level for some callers.
+// The key is a glob pattern, as in path.Match, with the form "package" or "package.Function",
+// the package is matched against the import path of the package of the caller or its last elements,
+// as "billing", "internal/billing" or "github.com/acme/service/internal/billing",
+// and the function against the function name of the caller.
+// The most specific matching rule wins: a rule for functions wins over a rule for packages,
+// and between them the rule with more characters which are not wildcards wins.
+// usage: WithLevelRules(map[string]ionlog.Level{"internal/billing": ionlog.DebugLevel, "internal/billing.Charge*": ionlog.WarnLevel})
+func WithLevelRules(rules map[string]Level) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().AddLevelRules(rules)
+	}
+}
+
+// WithoutLevelRules removes the level rules.
+// Use the pattern of the rule to remove.
+func WithoutLevelRules(patterns ...string) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().DeleteLevelRules(patterns...)
+	}
+}
+
+// WithLogOnceMaxRecords sets the max number of records kept for the log once and periodic log functions,
+// the least recently used records are forgotten when it is reached, so their messages can be logged again.
+// The default is 10000, zero or less removes the limit.
+func WithLogOnceMaxRecords(max int) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Memory().SetMaxRecords(max)
+	}
+}
+
+// WithLogOnceTTL sets the time a record of the log once and periodic log functions
+// is kept without being used. The records are kept forever by default.
+func WithLogOnceTTL(ttl time.Duration) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().Memory().SetTTL(ttl)
+	}
+}
+
+// WithDuplicateSuppression collapses the runs of entries with the same level, message and caller:
+// the first entry is written and the repeats are written as one summary entry, with the
+// "repeat_count", "first_time" and "last_time" fields, on the next different entry or after the interval.
+// Zero or less disables the suppression, it is the default.
+// usage: WithDuplicateSuppression(30 * time.Second)
+func WithDuplicateSuppression(interval time.Duration) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetDuplicateSuppression(interval)
+	}
+}
+
+// WithSampling samples the entries of every call site, a call site is the file and line of the caller.
+// The written entries carry the "sampled_dropped" field, with the number of entries
+// of the call site discarded since the last written one.
+// usage: WithSampling(ionlog.Sampling{First: 10, Thereafter: 100, Interval: time.Second})
+func WithSampling(sampling Sampling) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetSampling(sampling)
+	}
+}
+
+// WithLevelSampling samples the entries of the level, in place of the sampling set by WithSampling.
+// A Sampling without interval disables the sampling of the level.
+// usage: WithLevelSampling(ionlog.ErrorLevel, ionlog.Sampling{})
+func WithLevelSampling(level Level, sampling Sampling) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetLevelSampling(level, sampling)
+	}
+}
+
+// WithoutSampling disables the sampling of all levels, it is the default.
+func WithoutSampling() customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().DeleteSampling()
+	}
+}
+
+// WithStackTraceLevel adds the stack trace of the caller, as the "stack" field,
+// to the entries of the level and above. The frames skipped by the caller stack depth,
+// like the ones of wrapper functions, are not in the stack.
+// usage: WithStackTraceLevel(ionlog.ErrorLevel)
+func WithStackTraceLevel(level Level) customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().SetStackTraceLevel(level)
+	}
+}
+
+// WithoutStackTrace removes the stack trace of the entries, it is the default.
+func WithoutStackTrace() customAttrs {
+	return func(i service.ICoreService) {
+		i.LogEngine().DisableStackTrace()
+	}
+}
+
+// WithExitFunc sets the function called by Fatal and Fatalf to exit the program,
+// it is os.Exit by default. A nil function restores os.Exit.
+// usage: WithExitFunc(func(code int) { cleanup(); os.Exit(code) })
+func WithExitFunc(fn func(code int)) customAttrs {
+	return func(i service.ICoreService) {
+		i.SetExitFunc(fn)
+	}
+}
+
 // WithCallerInfoDepth sets the caller stack depth for log functions.
 // The depth determines how many stack frames to skip when retrieving caller information.
 // Default depth is 2. For LogOnce functions, the depth is automatically increased by 1.
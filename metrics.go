@@ -0,0 +1,155 @@
+package ionlog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+)
+
+// WriterStats are the health, the bytes written and the write errors of a writer.
+type WriterStats = logengine.WriterStats
+
+// WriterState is the health of a writer.
+type WriterState = logengine.WriterState
+
+const (
+	WriterHealthy  = logengine.WriterHealthy
+	WriterFailing  = logengine.WriterFailing
+	WriterDisabled = logengine.WriterDisabled
+)
+
+// Metrics are the metrics of the logger pipeline.
+type Metrics struct {
+	// Entries are the entries logged by level, including the dropped ones.
+	Entries map[Level]uint64
+	Queue   ReportQueueStats
+	Writers []WriterStats
+
+	// Rotations and DeletedFiles are the rotations performed and the files deleted
+	// to keep the logs folder below its max size.
+	Rotations    uint64
+	DeletedFiles uint64
+}
+
+// levels are the levels exposed by the metrics handler, every level is exposed even without entries.
+var levels = []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, PanicLevel, FatalLevel}
+
+// Stats returns the metrics of the logger pipeline.
+func (l *Logger) Stats() Metrics {
+	engine := l.core.LogEngine().Metrics()
+	rotation := l.core.RotationStats()
+
+	return Metrics{
+		Entries:      engine.Entries,
+		Queue:        engine.Queue,
+		Writers:      engine.Writers,
+		Rotations:    rotation.Rotations,
+		DeletedFiles: rotation.DeletedFiles,
+	}
+}
+
+// MetricsHandler returns a handler which serves the metrics of the logger in the Prometheus text format.
+// usage: http.Handle("/metrics", l.MetricsHandler())
+func (l *Logger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(l.Stats().prometheus())
+	})
+}
+
+// prometheus encodes the metrics in the Prometheus text format.
+func (m Metrics) prometheus() []byte {
+	var buf bytes.Buffer
+
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("ionlog_entries_total", "counter", "Entries logged by level, including the dropped ones.")
+	for _, level := range levels {
+		fmt.Fprintf(&buf, "ionlog_entries_total{level=%q} %d\n", level.String(), m.Entries[level])
+	}
+
+	header("ionlog_dropped_entries_total", "counter", "Entries dropped by level because the reports queue was full.")
+	for _, level := range levels {
+		fmt.Fprintf(&buf, "ionlog_dropped_entries_total{level=%q} %d\n", level.String(), m.Queue.Dropped[level])
+	}
+
+	header("ionlog_spilled_entries_total", "counter", "Entries spilled to disk because the reports queue was full.")
+	fmt.Fprintf(&buf, "ionlog_spilled_entries_total %d\n", m.Queue.Spilled)
+
+	header("ionlog_queue_depth", "gauge", "Entries in the reports queue.")
+	fmt.Fprintf(&buf, "ionlog_queue_depth %d\n", m.Queue.Queued)
+
+	header("ionlog_queue_capacity", "gauge", "Capacity of the reports queue.")
+	fmt.Fprintf(&buf, "ionlog_queue_capacity %d\n", m.Queue.Capacity)
+
+	header("ionlog_queue_high_water_mark", "gauge", "Highest number of entries in the reports queue.")
+	fmt.Fprintf(&buf, "ionlog_queue_high_water_mark %d\n", m.Queue.HighWaterMark)
+
+	writers := writersByName(m.Writers)
+
+	header("ionlog_writer_bytes_total", "counter", "Bytes written by writer.")
+	for _, w := range writers {
+		fmt.Fprintf(&buf, "ionlog_writer_bytes_total{name=\"%s\"} %d\n", escapeLabel(w.Name), w.Bytes)
+	}
+
+	header("ionlog_writer_errors_total", "counter", "Write errors by writer.")
+	for _, w := range writers {
+		fmt.Fprintf(&buf, "ionlog_writer_errors_total{name=\"%s\"} %d\n", escapeLabel(w.Name), w.Errors)
+	}
+
+	header("ionlog_writer_dropped_entries_total", "counter", "Entries dropped by writer because its buffer was full or it was disabled.")
+	for _, w := range writers {
+		fmt.Fprintf(&buf, "ionlog_writer_dropped_entries_total{name=\"%s\"} %d\n", escapeLabel(w.Name), w.Dropped)
+	}
+
+	header("ionlog_writer_up", "gauge", "Whether the writer is enabled, a writer is disabled by the circuit breaker.")
+	for _, w := range writers {
+		up := 1
+		if w.State == WriterDisabled {
+			up = 0
+		}
+		fmt.Fprintf(&buf, "ionlog_writer_up{name=\"%s\"} %d\n", escapeLabel(w.Name), up)
+	}
+
+	header("ionlog_rotations_total", "counter", "Rotations of the log file.")
+	fmt.Fprintf(&buf, "ionlog_rotations_total %d\n", m.Rotations)
+
+	header("ionlog_deleted_files_total", "counter", "Log files deleted to keep the logs folder below its max size.")
+	fmt.Fprintf(&buf, "ionlog_deleted_files_total %d\n", m.DeletedFiles)
+
+	return buf.Bytes()
+}
+
+// writersByName merges the metrics of the writers with the same name, so every series
+// of the Prometheus output has a distinct name label, which does not shift when a writer is deleted.
+// The counters are summed and the merged writer is disabled when one of them is disabled.
+func writersByName(writers []WriterStats) []WriterStats {
+	merged := make([]WriterStats, 0, len(writers))
+	for _, w := range writers {
+		index := slices.IndexFunc(merged, func(m WriterStats) bool { return m.Name == w.Name })
+		if index < 0 {
+			merged = append(merged, w)
+			continue
+		}
+
+		m := &merged[index]
+		m.Bytes += w.Bytes
+		m.Errors += w.Errors
+		m.Dropped += w.Dropped
+		m.State = max(m.State, w.State)
+	}
+	return merged
+}
+
+// labelEscaper escapes a label value as the Prometheus text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,146 @@
+package ionlog
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestStats(t *testing.T) {
+	t.Run("should count the entries and the bytes written", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf, failWriter{}))
+		l.Start()
+
+		l.Info("first")
+		l.Info("second")
+		l.Error("third")
+
+		l.Stop()
+
+		stats := l.Stats()
+		if stats.Entries[InfoLevel] != 2 || stats.Entries[ErrorLevel] != 1 {
+			t.Errorf("expected 2 info and 1 error entries, but got %v", stats.Entries)
+		}
+		if stats.Queue.HighWaterMark == 0 {
+			t.Errorf("expected the high-water mark of the queue to be set")
+		}
+		if len(stats.Writers) != 2 {
+			t.Fatalf("expected the stats of 2 writers, but got %v", stats.Writers)
+		}
+		if stats.Writers[0].Bytes != uint64(buf.buf.Len()) || stats.Writers[0].Errors != 0 {
+			t.Errorf("expected %v bytes written and no error, but got %+v", buf.buf.Len(), stats.Writers[0])
+		}
+		if stats.Writers[1].Errors != 3 {
+			t.Errorf("expected 3 write errors, but got %+v", stats.Writers[1])
+		}
+	})
+}
+
+func TestMetricsHandler(t *testing.T) {
+	t.Run("should serve the metrics in the Prometheus text format", func(t *testing.T) {
+		l := New(WithWriters(io.Discard))
+		l.Start()
+		l.Warn("disk almost full")
+		l.Stop()
+
+		rec := httptest.NewRecorder()
+		l.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+			t.Errorf("expected the Prometheus content type, but got %q", ct)
+		}
+
+		body := rec.Body.String()
+		for _, line := range []string{
+			"# TYPE ionlog_entries_total counter",
+			`ionlog_entries_total{level="WARN"} 1`,
+			`ionlog_dropped_entries_total{level="ERROR"} 0`,
+			"ionlog_queue_capacity 100",
+			`ionlog_writer_bytes_total{name="io.discard"}`,
+			"ionlog_rotations_total 0",
+		} {
+			if !strings.Contains(body, line) {
+				t.Errorf("expected the metrics to contain %q, but got\n%s", line, body)
+			}
+		}
+	})
+
+	t.Run("should merge the writers with the same name", func(t *testing.T) {
+		m := Metrics{Writers: []WriterStats{
+			{Name: "*bytes.Buffer", State: WriterHealthy, Bytes: 10, Errors: 1},
+			{Name: "/dev/stdout", State: WriterHealthy, Bytes: 5},
+			{Name: "*bytes.Buffer", State: WriterDisabled, Bytes: 20, Dropped: 2},
+		}}
+
+		body := string(m.prometheus())
+		for _, line := range []string{
+			`ionlog_writer_bytes_total{name="*bytes.Buffer"} 30`,
+			`ionlog_writer_errors_total{name="*bytes.Buffer"} 1`,
+			`ionlog_writer_dropped_entries_total{name="*bytes.Buffer"} 2`,
+			`ionlog_writer_up{name="*bytes.Buffer"} 0`,
+			`ionlog_writer_up{name="/dev/stdout"} 1`,
+		} {
+			if !strings.Contains(body, line) {
+				t.Errorf("expected the metrics to contain %q, but got\n%s", line, body)
+			}
+		}
+
+		if n := strings.Count(body, `ionlog_writer_bytes_total{name="*bytes.Buffer"}`); n != 1 {
+			t.Errorf("expected a single series per writer name, but got %v", n)
+		}
+	})
+
+	t.Run("should escape the label values", func(t *testing.T) {
+		if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+			t.Errorf("expected the escaped label, but got %q", got)
+		}
+	})
+}
+
+func TestWithWriterErrorHandler(t *testing.T) {
+	t.Run("should report the failed writes and disable the failing writer", func(t *testing.T) {
+		var failures int
+		l := New(
+			WithWriters(io.Discard, failWriter{}),
+			WithWriterCircuitBreaker(CircuitBreaker{Failures: 2, Cooldown: time.Hour}),
+			WithWriterErrorHandler(func(w io.Writer, err error) {
+				if _, ok := w.(failWriter); ok && err != nil {
+					failures++
+				}
+			}),
+		)
+		l.Start()
+
+		for range 3 {
+			l.Info("entry")
+		}
+
+		l.Stop()
+
+		if failures != 2 {
+			t.Errorf("expected 2 failed writes before the writer is disabled, but got %v", failures)
+		}
+
+		stats := l.Stats()
+		if stats.Writers[0].State != WriterHealthy || stats.Writers[1].State != WriterDisabled {
+			t.Errorf("expected the failing writer to be disabled, but got %+v", stats.Writers)
+		}
+
+		rec := httptest.NewRecorder()
+		l.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		if !strings.Contains(rec.Body.String(), `ionlog_writer_up{name="ionlog.failWriter"} 0`) {
+			t.Errorf("expected the disabled writer to be down, but got\n%s", rec.Body.String())
+		}
+	})
+}
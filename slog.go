@@ -0,0 +1,239 @@
+package ionlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+// slogHandler is a slog.Handler which sends the records to a Logger.
+type slogHandler struct {
+	logger *Logger
+	goas   []groupOrAttrs
+}
+
+// groupOrAttrs holds a group opened by WithGroup or the attributes added by WithAttrs,
+// in the order they were called.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a slog.Handler which sends the records to the default logger,
+// so the logs of libraries using log/slog end up in the same writers of ionlog.
+// usage: slog.SetDefault(slog.New(ionlog.NewSlogHandler()))
+func NewSlogHandler() slog.Handler {
+	return logger.SlogHandler()
+}
+
+// SlogHandler returns a slog.Handler which sends the records to the logger.
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// slogLevel maps the slog level onto the ionlog level,
+// levels between two slog levels are mapped to the lower one.
+func slogLevel(level slog.Level) logengine.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logengine.Trace
+	case level < slog.LevelInfo:
+		return logengine.Debug
+	case level < slog.LevelWarn:
+		return logengine.Info
+	case level < slog.LevelError:
+		return logengine.Warn
+	default:
+		return logengine.Error
+	}
+}
+
+// Enabled reports whether the handler may handle records at the given level,
+// the level rules are checked by Handle with the caller of the record.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.core.LogEngine().Enabled(slogLevel(level))
+}
+
+// Handle sends the record to the logger, with the caller of the record PC.
+func (h *slogHandler) Handle(_ context.Context, rec slog.Record) error {
+	engine := h.logger.core.LogEngine()
+
+	level := slogLevel(rec.Level)
+	callerInfo := runtimeinfo.GetCallerInfoFromPC(rec.PC)
+	if !engine.Enabled(level) || !engine.EnabledFor(level, callerInfo) {
+		return nil
+	}
+
+	written, sampled, dropped := engine.Sample(level, callerInfo)
+	if !written {
+		return nil
+	}
+
+	fields := h.logger.entryFields(nil, h.fields(rec))
+	if sampled {
+		fields = append(fields, Field{Key: sampledDroppedKey, Value: dropped})
+	}
+
+	r := logengine.ReportType{
+		Time:       rec.Time, // a zero time omits the time field, as the slog handlers do
+		Level:      level,
+		Msg:        rec.Message,
+		CallerInfo: callerInfo,
+		Fields:     fields,
+	}
+	if engine.StackTraceEnabled(level) {
+		r.Stack = runtimeinfo.GetStackFromPC(rec.PC)
+	}
+
+	h.logger.report(r)
+
+	return nil
+}
+
+// WithAttrs returns a handler whose records carry the given attributes.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a handler which nests the following attributes in the group.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *slogHandler) withGroupOrAttrs(goa groupOrAttrs) *slogHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h2.goas)-1] = goa
+	return &h2
+}
+
+// fields converts the handler attributes and the record attributes into fields,
+// the attributes of a group are written as a nested JSON object.
+func (h *slogHandler) fields(rec slog.Record) []Field {
+	goas := h.goas
+	if rec.NumAttrs() == 0 {
+		// groups without attributes are ignored
+		for len(goas) > 0 && goas[len(goas)-1].group != "" {
+			goas = goas[:len(goas)-1]
+		}
+	}
+
+	var fields []Field
+	var group map[string]any
+
+	put := func(key string, value any) {
+		if group == nil {
+			fields = append(fields, Field{Key: key, Value: value})
+			return
+		}
+		group[key] = value
+	}
+
+	add := func(a slog.Attr) {
+		key, value, ok := slogAttr(a)
+		if !ok {
+			return
+		}
+		if inline, isGroup := value.(map[string]any); isGroup && key == "" {
+			// a group without key has its attributes inlined
+			for _, k := range slices.Sorted(maps.Keys(inline)) {
+				put(k, inline[k])
+			}
+			return
+		}
+		put(key, value)
+	}
+
+	for _, goa := range goas {
+		if goa.group != "" {
+			nested := map[string]any{}
+			if group == nil {
+				fields = append(fields, Field{Key: goa.group, Value: nested})
+			} else {
+				group[goa.group] = nested
+			}
+			group = nested
+			continue
+		}
+
+		for _, a := range goa.attrs {
+			add(a)
+		}
+	}
+
+	rec.Attrs(func(a slog.Attr) bool {
+		add(a)
+		return true
+	})
+
+	return fields
+}
+
+// slogAttr returns the key and the value of the attribute,
+// it returns false when the attribute must be ignored.
+func slogAttr(a slog.Attr) (string, any, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return "", nil, false
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return a.Key, slogValue(a.Value), true
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return "", nil, false
+	}
+
+	group := make(map[string]any, len(attrs))
+	for _, ga := range attrs {
+		key, value, ok := slogAttr(ga)
+		if !ok {
+			continue
+		}
+		if inline, isGroup := value.(map[string]any); isGroup && key == "" {
+			// a group without key has its attributes inlined
+			for k, v := range inline {
+				group[k] = v
+			}
+			continue
+		}
+		group[key] = value
+	}
+
+	return a.Key, group, true
+}
+
+// slogValue returns the value of a resolved slog.Value which is not a group.
+func slogValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	default:
+		return v.Any()
+	}
+}
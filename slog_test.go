@@ -0,0 +1,180 @@
+package ionlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+)
+
+func TestSlogLevel(t *testing.T) {
+	testCases := [...]struct {
+		level    slog.Level
+		expected logengine.Level
+	}{
+		{level: slog.LevelDebug - 4, expected: logengine.Trace},
+		{level: slog.LevelDebug, expected: logengine.Debug},
+		{level: slog.LevelInfo, expected: logengine.Info},
+		{level: slog.LevelInfo + 2, expected: logengine.Info},
+		{level: slog.LevelWarn, expected: logengine.Warn},
+		{level: slog.LevelError, expected: logengine.Error},
+		{level: slog.LevelError + 4, expected: logengine.Error},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			if level := slogLevel(tt.level); level != tt.expected {
+				t.Errorf("expected level to be %v, but got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	t.Run("should send the records to the logger", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		sl := slog.New(l.SlogHandler())
+		sl.Warn("disk almost full",
+			"free", 10,
+			"ratio", 0.5,
+			"ok", false,
+			"elapsed", time.Second,
+			"err", errors.New("quota"),
+		)
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+
+		e := entries[0]
+		expected := map[string]any{
+			"level":   "WARN",
+			"msg":     "disk almost full",
+			"free":    float64(10),
+			"ratio":   0.5,
+			"ok":      false,
+			"elapsed": float64(time.Second),
+			"err":     "quota",
+			"file":    "slog_test.go",
+			"package": "ionlog",
+		}
+		for k, v := range expected {
+			if e[k] != v {
+				t.Errorf("expected %q to be %v, but got %v", k, v, e[k])
+			}
+		}
+
+		if e["function"] != "TestSlogHandler.func1" {
+			t.Errorf("expected the caller function of the record, but got %v", e["function"])
+		}
+	})
+
+	t.Run("should add the stack from the caller of the record", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf), WithStackTraceLevel(ErrorLevel))
+		l.Start()
+
+		slog.New(l.SlogHandler()).Error("failed")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		if f := firstStackFunction(t, entries[0]); !strings.HasSuffix(f, ".TestSlogHandler.func2") {
+			t.Errorf("expected the stack to start at the caller of the record, but got %q", f)
+		}
+	})
+
+	t.Run("should nest the attributes of groups", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		sl := slog.New(l.SlogHandler()).With("service", "billing").WithGroup("req").With("id", "abc")
+		sl.Info("handled", "status", 200, slog.Group("user", "name", "alice"), slog.Group("", "inline", true))
+		sl.WithGroup("empty").Info("no attrs")
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+
+		if entries[0]["service"] != "billing" {
+			t.Errorf("expected the top level attribute, but got %v", entries[0])
+		}
+
+		expectedReq := map[string]any{
+			"id":     "abc",
+			"status": float64(200),
+			"user":   map[string]any{"name": "alice"},
+			"inline": true,
+		}
+		if !reflect.DeepEqual(entries[0]["req"], expectedReq) {
+			t.Errorf("expected the group to be %v, but got %v", expectedReq, entries[0]["req"])
+		}
+
+		expectedReq = map[string]any{"id": "abc"}
+		if !reflect.DeepEqual(entries[1]["req"], expectedReq) {
+			t.Errorf("expected the empty group to be ignored, but got %v", entries[1]["req"])
+		}
+	})
+
+	t.Run("should enable the trace level only on trace mode", func(t *testing.T) {
+		l := New()
+		h := l.SlogHandler()
+
+		if !h.Enabled(context.Background(), slog.LevelDebug) {
+			t.Error("expected the debug level to be enabled")
+		}
+
+		if h.Enabled(context.Background(), slog.LevelDebug-4) {
+			t.Error("expected the trace level to be disabled")
+		}
+
+		l.SetAttributes(WithTraceMode(true))
+		if !h.Enabled(context.Background(), slog.LevelDebug-4) {
+			t.Error("expected the trace level to be enabled")
+		}
+	})
+}
+
+func TestSlogConformance(t *testing.T) {
+	var buf *mockBufferWriter
+	var l *Logger
+
+	newHandler := func(t *testing.T) slog.Handler {
+		buf = &mockBufferWriter{}
+		l = New(WithWriters(buf))
+		l.Start()
+		t.Cleanup(l.Stop)
+		return l.SlogHandler()
+	}
+
+	result := func(t *testing.T) map[string]any {
+		l.Flush()
+
+		entries := buf.entries(t)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, but got %v", entries)
+		}
+		return entries[0]
+	}
+
+	slogtest.Run(t, newHandler, result)
+}
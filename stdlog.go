@@ -0,0 +1,128 @@
+package ionlog
+
+import (
+	"log"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/IonicHealthUsa/ionlog/internal/core/logengine"
+	"github.com/IonicHealthUsa/ionlog/internal/core/runtimeinfo"
+)
+
+// stdLogWriter is the output of the standard library log package,
+// every line written by log is sent to a Logger.
+type stdLogWriter struct {
+	logger *Logger
+	level  Level
+}
+
+// stdLogExits are the functions of the log package which exit or panic after the line is written,
+// with the level of their lines.
+var stdLogExits = map[string]logengine.Level{
+	"log.Fatal": logengine.Fatal, "log.Fatalf": logengine.Fatal, "log.Fatalln": logengine.Fatal,
+	"log.Panic": logengine.Panic, "log.Panicf": logengine.Panic, "log.Panicln": logengine.Panic,
+	"log.(*Logger).Fatal": logengine.Fatal, "log.(*Logger).Fatalf": logengine.Fatal, "log.(*Logger).Fatalln": logengine.Fatal,
+	"log.(*Logger).Panic": logengine.Panic, "log.(*Logger).Panicf": logengine.Panic, "log.(*Logger).Panicln": logengine.Panic,
+}
+
+// RedirectStdLog sends the output of the standard library log package to the default logger,
+// every line is logged with the given level and the caller of the log function.
+// It returns a function which restores the previous output and flags of the log package.
+// usage: defer ionlog.RedirectStdLog(ionlog.InfoLevel)()
+func RedirectStdLog(level Level) (restore func()) {
+	return logger.RedirectStdLog(level)
+}
+
+// RedirectStdLog sends the output of the standard library log package to the logger,
+// every line is logged with the given level and the caller of the log function.
+// The lines of the Fatal and Panic functions are logged with the FATAL and PANIC levels, whatever the min level,
+// and they are written and synced before the log package exits or panics.
+// It returns a function which restores the previous output and flags of the log package.
+func (l *Logger) RedirectStdLog(level Level) (restore func()) {
+	prevOutput := log.Writer()
+	prevFlags := log.Flags()
+
+	// the time and the caller are added by ionlog
+	log.SetFlags(prevFlags & log.Lmsgprefix)
+	log.SetOutput(&stdLogWriter{logger: l, level: level})
+
+	return func() {
+		log.SetOutput(prevOutput)
+		log.SetFlags(prevFlags)
+	}
+}
+
+// Write logs the line written by the log package.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	engine := w.logger.core.LogEngine()
+
+	level, exiting := stdLogExit()
+	if !exiting {
+		level = w.level
+		if !engine.Enabled(level) {
+			return len(p), nil
+		}
+	}
+
+	callerInfo := runtimeinfo.GetCallerInfoOutside(2, "log")
+
+	var fields []Field
+	if exiting {
+		// the line before the exit is never filtered nor sampled
+		fields = w.logger.entryFields(nil, nil)
+	} else {
+		if !engine.EnabledFor(level, callerInfo) {
+			return len(p), nil
+		}
+
+		written, sampled, dropped := engine.Sample(level, callerInfo)
+		if !written {
+			return len(p), nil
+		}
+
+		fields = w.logger.entryFields(nil, nil)
+		if sampled {
+			fields = append(fields, Field{Key: sampledDroppedKey, Value: dropped})
+		}
+	}
+
+	r := logengine.ReportType{
+		Time:       time.Now(),
+		Level:      level,
+		Msg:        strings.TrimSuffix(string(p), "\n"),
+		CallerInfo: callerInfo,
+		Fields:     fields,
+	}
+	if engine.StackTraceEnabled(level) {
+		r.Stack = runtimeinfo.GetStackOutside(2, "log")
+	}
+
+	// the panic and fatal reports are written after the queued reports, from the caller
+	w.logger.report(r)
+
+	if exiting {
+		// the line is synced before the exit
+		engine.Writer().Sync()
+	}
+
+	return len(p), nil
+}
+
+// stdLogExit returns the level of the line written by a function of the log package which exits or panics,
+// and false when the line is written by another function.
+func stdLogExit() (logengine.Level, bool) {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if level, ok := stdLogExits[frame.Function]; ok {
+			return level, true
+		}
+		if !more {
+			return 0, false
+		}
+	}
+}
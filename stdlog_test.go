@@ -0,0 +1,100 @@
+package ionlog
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	t.Run("should log the lines of the log package with the caller", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		l := New(WithWriters(buf))
+		l.Start()
+
+		restore := l.RedirectStdLog(WarnLevel)
+		log.Printf("retrying %d", 3)
+		log.Print("done")
+		restore()
+
+		l.Stop()
+
+		entries := buf.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, but got %v", entries)
+		}
+
+		if entries[0]["msg"] != "retrying 3" || entries[1]["msg"] != "done" {
+			t.Errorf("expected the messages without the new line, but got %v", entries)
+		}
+
+		for _, e := range entries {
+			if e["level"] != "WARN" {
+				t.Errorf("expected level to be %q, but got %q", "WARN", e["level"])
+			}
+			if e["file"] != "stdlog_test.go" || e["function"] != "TestRedirectStdLog.func1" {
+				t.Errorf("expected the caller of the log function, but got %v", e)
+			}
+		}
+	})
+
+	t.Run("should write the lines of the log package before it panics", func(t *testing.T) {
+		buf := &mockBufferWriter{}
+		// the logger is not started, so only the entries written from the caller are on the buffer,
+		// and the redirect level is below the min level, so only the panic lines are logged
+		l := New(WithWriters(buf), WithMinLevel(ErrorLevel))
+
+		restore := l.RedirectStdLog(InfoLevel)
+		defer restore()
+
+		for _, panicf := range []func(format string, v ...any){log.Panicf, log.Default().Panicf} {
+			func() {
+				defer func() { _ = recover() }()
+				panicf("unrecoverable %v", "state")
+			}()
+		}
+		log.Print("queued")
+
+		entries := buf.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("expected the panic lines written before the panic, but got %v", entries)
+		}
+		for _, e := range entries {
+			if e["msg"] != "unrecoverable state" || e["level"] != "PANIC" {
+				t.Errorf("expected the panic line with the PANIC level, but got %v", e)
+			}
+		}
+	})
+
+	t.Run("should restore the output and flags of the log package", func(t *testing.T) {
+		origOutput, origFlags := log.Writer(), log.Flags()
+		defer func() {
+			log.SetOutput(origOutput)
+			log.SetFlags(origFlags)
+		}()
+
+		prev := &bytes.Buffer{}
+		log.SetOutput(prev)
+		log.SetFlags(log.Lshortfile)
+
+		restore := New().RedirectStdLog(InfoLevel)
+		if log.Flags() != 0 {
+			t.Errorf("expected the flags to be cleared, but got %v", log.Flags())
+		}
+
+		restore()
+
+		if log.Writer() != prev {
+			t.Error("expected the previous output to be restored")
+		}
+		if log.Flags() != log.Lshortfile {
+			t.Errorf("expected the flags to be restored, but got %v", log.Flags())
+		}
+
+		log.Print("after restore")
+		if !strings.Contains(prev.String(), "after restore") {
+			t.Errorf("expected the log to be written on the previous output, but got %q", prev.String())
+		}
+	})
+}